@@ -17,7 +17,6 @@ package cmd
 import (
 	"bufio"
 	"fmt"
-	"go/build"
 	"go/token"
 	"io"
 	"io/ioutil"
@@ -32,6 +31,7 @@ import (
 	"github.com/cvgw/gocheckcov/pkg/coverage/analyzer"
 	"github.com/cvgw/gocheckcov/pkg/coverage/config"
 	"github.com/cvgw/gocheckcov/pkg/coverage/files"
+	"github.com/cvgw/gocheckcov/pkg/coverage/parser/profile"
 	"github.com/cvgw/gocheckcov/pkg/coverage/reporter"
 	"github.com/spf13/cobra"
 )
@@ -39,11 +39,25 @@ import (
 var (
 	noConfig       bool
 	configFile     string
-	ProfileFile    string
+	ProfileFiles   []string
+	coverDirs      []string
 	printFunctions bool
 	printSrc       bool
+	printUncovered bool
+	printFiles     bool
+	explain        bool
+	explainConfig  bool
 	minCov         float64
+	minBranchCov   float64
+	minLineCov     float64
+	minFuncCov     float64
+	maxCRAP        float64
+	minAPICov      float64
+	waiverDays     int
 	skipDirs       string
+	buildTags      string
+	strict         bool
+	profileOnly    bool
 	checkCmd       = &cobra.Command{
 		Use:   "check",
 		Short: "Check whether pkg coverage meets specified minimum",
@@ -56,72 +70,195 @@ var (
 	}
 )
 
+type analysis struct {
+	packageToFunctions map[string][]profile.FunctionCoverage
+	excluded           []string
+	staleErrs          []error
+	resolver           files.PathResolver
+}
+
 func runCheckCommand(args []string) error {
 	if verbose {
 		log.SetLevel(log.DebugLevel)
 	}
 
+	a, configs, err := analyzeCheck(args)
+	if err != nil {
+		return err
+	}
+
+	var generated analyzer.Generated
+
+	a.packageToFunctions, generated = excludeGenerated(a.packageToFunctions, configs)
+
+	cliL := reporter.NewCliTabLogger()
+	defer cliL.Close()
+
+	setPrintOptions()
+
+	v := reporter.Verifier{
+		Out:               cliL,
+		PrintFunctions:    printFunctions,
+		PrintSrc:          printSrc,
+		PrintUncovered:    printUncovered,
+		PrintFiles:        printFiles,
+		Explain:           explain,
+		MinCov:            minCov,
+		MinBranchCov:      minBranchCov,
+		MinLineCov:        minLineCov,
+		MinFunctionCov:    minFuncCov,
+		MaxCRAP:           maxCRAP,
+		MinAPICov:         minAPICov,
+		Resolver:          a.resolver,
+		ProfileOnly:       profileOnly,
+		WaiverWarningDays: waiverDays,
+	}
+
+	if explainConfig {
+		return v.PrintConfigs(reporter.SortedPackages(a.packageToFunctions), configs)
+	}
+
+	v.PrintExcludedFiles(a.excluded)
+	v.PrintGenerated(generated)
+	v.PrintProfileErrors(a.staleErrs)
+
+	if strict && len(a.staleErrs) > 0 {
+		err := fmt.Errorf("coverage profile is out of date for %v files", len(a.staleErrs))
+		cliL.Printf("%v\n", err)
+
+		return err
+	}
+
+	if err := v.ReportPackageCoverage(a.packageToFunctions, configs); err != nil {
+		cliL.Printf("%v", err)
+		return err
+	}
+
+	return nil
+}
+
+// analyzeCheck analyzes the packages of args, or only the coverage profiles
+// with --profile-only, and loads the config of each package
+func analyzeCheck(args []string) (analysis, map[string]*config.ConfigFile, error) {
+	// fail before any analysis if binary coverage data can't be read
+	if len(coverDirs) > 0 {
+		if err := checkCovdata(); err != nil {
+			return analysis{}, nil, err
+		}
+	}
+
+	var a analysis
+
+	var err error
+
+	if profileOnly {
+		a, err = analyzeProfiles()
+	} else {
+		a, err = analyzeSource(args)
+	}
+
+	if err != nil {
+		return analysis{}, nil, err
+	}
+
+	configs, err := loadConfigs(a)
+	if err != nil {
+		return analysis{}, nil, err
+	}
+
+	return a, configs, nil
+}
+
+// setPrintOptions turns on what --print-src needs and turns off the output
+// --profile-only can't produce
+func setPrintOptions() {
+	if printSrc {
+		printFunctions = true
+	}
+
+	if profileOnly && printFunctions {
+		log.Print("function coverage is not available with --profile-only")
+
+		printFunctions = false
+		printSrc = false
+	}
+
+	if profileOnly && printUncovered {
+		log.Print("uncovered statements are not available with --profile-only")
+
+		printUncovered = false
+	}
+}
+
+func analyzeSource(args []string) (analysis, error) {
 	ignoreDirs := strings.Split(skipDirs, ",")
 	srcPath := files.SetSrcPath(args)
 	dir := srcPath
 
-	projectFiles, err := files.FilesForPath(dir, ignoreDirs)
+	projectFiles, err := files.FilesForPath(dir, ignoreDirs, buildFlags())
 	if err != nil {
 		log.Printf("could not retrieve project files from path %v %v", dir, err)
-		return err
+		return analysis{}, err
 	}
 
-	profilePath := ProfileFile
-	if profilePath == "" {
-		pf, e := runTestsAndGenerateProfile(srcPath)
-		if e != nil {
-			return e
-		}
+	resolver, err := files.NewPathResolver(srcPath)
+	if err != nil {
+		log.Printf("could not resolve import paths for %v %v", srcPath, err)
+		return analysis{}, err
+	}
 
-		profilePath = pf.Name()
+	profilePaths, cleanup, err := collectProfilePaths(srcPath, resolver)
+	defer cleanup()
 
-		defer func() {
-			if e := os.Remove(pf.Name()); e != nil {
-				log.Print(e)
-			}
-		}()
+	if err != nil {
+		return analysis{}, err
 	}
 
 	fset := token.NewFileSet()
-	goSrc := filepath.Join(build.Default.GOPATH, "src")
 
-	packageToFunctions, err := analyzer.MapPackagesToFunctions(profilePath, projectFiles, fset, goSrc)
+	packageToFunctions, staleErrs, err := analyzer.MapPackagesToFunctions(
+		profilePaths,
+		projectFiles.Files,
+		fset,
+		resolver,
+	)
 	if err != nil {
 		log.Print(err)
-		return err
+		return analysis{}, err
 	}
 
-	cfContent, err := getConfig()
-	if err != nil {
-		return err
-	}
+	return analysis{
+		packageToFunctions: packageToFunctions,
+		excluded:           projectFiles.Excluded,
+		staleErrs:          staleErrs,
+		resolver:           resolver,
+	}, nil
+}
 
-	cliL := reporter.NewCliTabLogger()
-	defer cliL.Close()
+// analyzeProfiles computes coverage from the profile blocks alone so no
+// source tree is needed
+func analyzeProfiles() (analysis, error) {
+	if len(ProfileFiles) == 0 && len(coverDirs) == 0 {
+		err := fmt.Errorf("--profile-only requires --profile-file or --cover-dir")
+		log.Print(err)
 
-	if printSrc {
-		printFunctions = true
+		return analysis{}, err
 	}
 
-	v := reporter.Verifier{
-		Out:            cliL,
-		PrintFunctions: printFunctions,
-		PrintSrc:       printSrc,
-		MinCov:         minCov,
-		GoSrcPath:      goSrc,
+	profilePaths, cleanup, err := collectProfilePaths("", files.PathResolver{})
+	defer cleanup()
+
+	if err != nil {
+		return analysis{}, err
 	}
 
-	if _, err := v.ReportCoverage(packageToFunctions, printFunctions, cfContent); err != nil {
-		cliL.Printf("%v", err)
-		return err
+	packageToFiles, err := analyzer.MapPackagesToFiles(profilePaths)
+	if err != nil {
+		log.Print(err)
+		return analysis{}, err
 	}
 
-	return nil
+	return analysis{packageToFunctions: packageToFiles}, nil
 }
 
 func init() {
@@ -129,6 +266,8 @@ func init() {
 
 	checkCmd.Flags().BoolVar(&printFunctions, "print-functions", false, "print coverage for individual functions")
 
+	checkCmd.Flags().BoolVar(&printFiles, "print-files", false, "print coverage for individual files")
+
 	checkCmd.Flags().BoolVar(
 		&printSrc,
 		"print-src",
@@ -136,6 +275,32 @@ func init() {
 		"print src coverage for each function (print-functions automatically set to true)",
 	)
 
+	checkCmd.Flags().BoolVar(
+		&printUncovered,
+		"print-uncovered",
+		false,
+		"print the file:line-line ranges of uncovered statements and the branches with arms that never ran",
+	)
+
+	checkCmd.Flags().BoolVar(&explain, "explain", false, "print which configuration rule applies to each package")
+
+	checkCmd.Flags().BoolVar(
+		&explainConfig,
+		"explain-config",
+		false,
+		"print the effective configuration of each package and the config files it was merged from, then exit",
+	)
+
+	checkCmd.Flags().BoolVar(&strict, "strict", false, "fail if the coverage profile is out of date with the source")
+
+	checkCmd.Flags().BoolVar(
+		&profileOnly,
+		"profile-only",
+		false,
+		"compute package coverage from the coverage profiles alone without reading source, "+
+			"function coverage is not reported",
+	)
+
 	checkCmd.Flags().BoolVar(&noConfig, "no-config", false, "do not read configuration from file")
 
 	checkCmd.Flags().Float64VarP(
@@ -146,7 +311,62 @@ func init() {
 		"minimum coverage percentage to enforce for all packages (defaults to 0)",
 	)
 
-	checkCmd.Flags().StringVarP(&ProfileFile, "profile-file", "p", "", "path to coverage profile file")
+	checkCmd.Flags().Float64Var(
+		&minAPICov,
+		"minimum-api-coverage",
+		0,
+		"minimum coverage percentage of exported functions and methods to enforce for all packages (defaults to 0)",
+	)
+
+	checkCmd.Flags().Float64Var(
+		&minBranchCov,
+		"minimum-branch-coverage",
+		0,
+		"minimum branch coverage percentage to enforce for all packages (defaults to 0)",
+	)
+
+	checkCmd.Flags().Float64Var(
+		&minLineCov,
+		"minimum-line-coverage",
+		0,
+		"minimum percentage of lines with an executed statement to enforce for all packages (defaults to 0)",
+	)
+
+	checkCmd.Flags().Float64Var(
+		&minFuncCov,
+		"minimum-function-coverage",
+		0,
+		"minimum percentage of functions with any executed statement to enforce for all packages (defaults to 0)",
+	)
+
+	checkCmd.Flags().IntVar(
+		&waiverDays,
+		"waiver-warning-days",
+		30,
+		"warn about waivers in the configuration which expire within this many days",
+	)
+
+	checkCmd.Flags().Float64Var(
+		&maxCRAP,
+		"maximum-crap-score",
+		0,
+		"fail if any function has a CRAP score above this maximum (defaults to 0, no maximum)",
+	)
+
+	checkCmd.Flags().StringSliceVarP(
+		&ProfileFiles,
+		"profile-file",
+		"p",
+		nil,
+		"path or glob of coverage profile files, can be repeated to merge multiple profiles",
+	)
+
+	checkCmd.Flags().StringSliceVar(
+		&coverDirs,
+		"cover-dir",
+		nil,
+		"GOCOVERDIR directory written by a binary built with go build -cover, can be repeated",
+	)
 
 	checkCmd.Flags().StringVarP(
 		&configFile,
@@ -163,25 +383,228 @@ func init() {
 		"vendor",
 		"command separted list of directories to skip when reporting coverage",
 	)
+
+	checkCmd.PersistentFlags().StringVar(
+		&buildTags,
+		"tags",
+		"",
+		"comma separated list of build tags to use when loading packages and running tests",
+	)
 }
 
-func getConfig() ([]byte, error) {
-	var cfContent []byte
+// loadConfigs returns the config of each analyzed package. A config file given
+// on the command line applies to every package, otherwise the config files in
+// each package directory and its parents up to the module root are merged.
+// Packages without any config file are left out so the flags apply to them.
+func loadConfigs(a analysis) (map[string]*config.ConfigFile, error) {
+	configs := make(map[string]*config.ConfigFile)
 
-	var err error
+	if noConfig {
+		return configs, nil
+	}
 
-	if !noConfig {
-		cfContent, err = config.GetConfigFile(configFile)
+	if configFile != "" {
+		cfContent, err := config.GetConfigFile(configFile)
 		if err != nil {
 			log.Debug(err)
 			return nil, err
 		}
+
+		if len(cfContent) == 0 {
+			return configs, nil
+		}
+
+		cfg, err := config.ParseConfigFile(cfContent)
+		if err != nil {
+			log.Printf("could not parse config file %v", err)
+			return nil, err
+		}
+
+		for pkg := range a.packageToFunctions {
+			configs[pkg] = &cfg
+		}
+
+		return configs, nil
 	}
 
-	return cfContent, nil
+	loader := config.NewLoader(a.resolver.Root)
+	loader.ImportPath = a.resolver.ImportPath
+
+	for pkg := range a.packageToFunctions {
+		cfg, err := loader.Load(a.resolver.FilePath(pkg))
+		if err != nil {
+			log.Printf("could not load config for package %v %v", pkg, err)
+			return nil, err
+		}
+
+		if len(cfg.Sources()) == 0 {
+			continue
+		}
+
+		configs[pkg] = &cfg
+	}
+
+	return configs, nil
+}
+
+// excludeGenerated leaves out the functions of generated files except in
+// packages whose config includes them
+func excludeGenerated(
+	packageToFunctions map[string][]profile.FunctionCoverage,
+	configs map[string]*config.ConfigFile,
+) (map[string][]profile.FunctionCoverage, analyzer.Generated) {
+	out := make(map[string][]profile.FunctionCoverage, len(packageToFunctions))
+	exclude := make(map[string][]profile.FunctionCoverage)
+
+	for pkg, functions := range packageToFunctions {
+		if cfg, ok := configs[pkg]; ok && cfg.IncludeGenerated {
+			out[pkg] = functions
+		} else {
+			exclude[pkg] = functions
+		}
+	}
+
+	kept, generated := analyzer.ExcludeGenerated(exclude)
+	for pkg, functions := range kept {
+		out[pkg] = functions
+	}
+
+	return out, generated
 }
 
-func runTestsAndGenerateProfile(srcPath string) (*os.File, error) {
+// collectProfilePaths returns the paths of all coverage profiles to analyze. Binary coverage
+// directories are converted to temporary text profiles, and when no profiles are given the tests
+// for srcPath are run to generate one. The returned cleanup func removes any temporary profiles.
+func collectProfilePaths(srcPath string, resolver files.PathResolver) ([]string, func(), error) {
+	var tmpFiles []string
+
+	cleanup := func() {
+		for _, f := range tmpFiles {
+			if e := os.Remove(f); e != nil {
+				log.Print(e)
+			}
+		}
+	}
+
+	profilePaths, err := expandProfilePaths(ProfileFiles)
+	if err != nil {
+		log.Print(err)
+		return nil, cleanup, err
+	}
+
+	if len(coverDirs) > 0 {
+		pf, e := convertCoverDirs(coverDirs)
+		if e != nil {
+			log.Print(e)
+			return nil, cleanup, e
+		}
+
+		tmpFiles = append(tmpFiles, pf.Name())
+		profilePaths = append(profilePaths, pf.Name())
+	}
+
+	if len(profilePaths) == 0 {
+		pf, e := runTestsAndGenerateProfile(srcPath, resolver)
+		if e != nil {
+			return nil, cleanup, e
+		}
+
+		tmpFiles = append(tmpFiles, pf.Name())
+		profilePaths = append(profilePaths, pf.Name())
+	}
+
+	return profilePaths, cleanup, nil
+}
+
+func convertCoverDirs(dirs []string) (*os.File, error) {
+	for _, dir := range dirs {
+		fi, err := os.Stat(dir)
+		if err != nil {
+			return nil, fmt.Errorf("could not read cover dir %v %v", dir, err)
+		}
+
+		if !fi.IsDir() {
+			return nil, fmt.Errorf("cover dir %v is not a directory", dir)
+		}
+	}
+
+	f, err := ioutil.TempFile("", "profile.out")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	c := exec.Command("go", "tool", "covdata", "textfmt", "-i="+strings.Join(dirs, ","), "-o="+f.Name())
+
+	out, err := c.CombinedOutput()
+	if err != nil {
+		if e := os.Remove(f.Name()); e != nil {
+			log.Print(e)
+		}
+
+		return nil, fmt.Errorf("could not convert cover dirs %v to a profile %v %s", dirs, err, out)
+	}
+
+	return f, nil
+}
+
+// checkCovdata returns an error if the go command can't be found or has no
+// covdata tool, which was added in Go 1.20
+func checkCovdata() error {
+	goCmd, err := exec.LookPath("go")
+	if err != nil {
+		return fmt.Errorf("--cover-dir needs the go command to read coverage data but it is not on PATH %v", err)
+	}
+
+	if out, err := exec.Command(goCmd, "tool", "-n", "covdata").CombinedOutput(); err != nil {
+		version := "an unknown version"
+		if v, e := exec.Command(goCmd, "env", "GOVERSION").Output(); e == nil {
+			version = strings.TrimSpace(string(v))
+		}
+
+		return fmt.Errorf(
+			"--cover-dir needs go tool covdata from Go 1.20 or later but %v is %v, use a text profile with "+
+				"--profile-file instead %s",
+			goCmd,
+			version,
+			strings.TrimSpace(string(out)),
+		)
+	}
+
+	return nil
+}
+
+func buildFlags() []string {
+	if buildTags == "" {
+		return nil
+	}
+
+	return []string{"-tags=" + buildTags}
+}
+
+func expandProfilePaths(patterns []string) ([]string, error) {
+	paths := make([]string, 0, len(patterns))
+
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid profile file pattern %v %v", pattern, err)
+		}
+
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no profile files found for %v", pattern)
+		}
+
+		paths = append(paths, matches...)
+	}
+
+	return paths, nil
+}
+
+func runTestsAndGenerateProfile(srcPath string, resolver files.PathResolver) (*os.File, error) {
 	f, err := ioutil.TempFile("", "profile.out")
 	if err != nil {
 		return nil, err
@@ -191,17 +614,22 @@ func runTestsAndGenerateProfile(srcPath string) (*os.File, error) {
 		"test",
 		"-coverprofile=" + f.Name(),
 	}
+	args = append(args, buildFlags()...)
 
 	absPath, err := filepath.Abs(srcPath)
 	if err != nil {
 		return nil, err
 	}
 
-	goSrcPath := filepath.Join(build.Default.GOPATH, "src")
-	pkgPath := strings.TrimPrefix(strings.TrimPrefix(absPath, goSrcPath), "/")
+	pkgPath := resolver.ImportPath(absPath)
 	args = append(args, pkgPath)
 	c := exec.Command("go", args...)
 
+	if resolver.ImportRoot != "" {
+		// go test must run inside of the module to resolve the import path
+		c.Dir = resolver.Root
+	}
+
 	stderr, err := c.StderrPipe()
 	if err != nil {
 		return nil, err
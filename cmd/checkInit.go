@@ -15,80 +15,188 @@
 package cmd
 
 import (
-	"go/build"
-	"go/token"
+	"fmt"
 	"io/ioutil"
-	"log"
 	"os"
-	"path/filepath"
-	"strings"
+
+	log "github.com/sirupsen/logrus"
 
 	"github.com/cvgw/gocheckcov/pkg/coverage/analyzer"
 	"github.com/cvgw/gocheckcov/pkg/coverage/config"
-	"github.com/cvgw/gocheckcov/pkg/coverage/files"
+	"github.com/cvgw/gocheckcov/pkg/coverage/diff"
+	"github.com/cvgw/gocheckcov/pkg/coverage/parser/profile"
+	"github.com/cvgw/gocheckcov/pkg/coverage/reporter"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v2"
 )
 
-// checkInitCmd represents the checkInit command
-var checkInitCmd = &cobra.Command{
-	Use:   "init",
-	Short: "Create a new config file using current coverage",
-	Long: `Create a new configuration file for gocheckcov which lists all of packages in the specified path and sets ` +
-		`the minimum converage percentage for each to the current coverage percentage for that package`,
-	Run: func(cmd *cobra.Command, args []string) {
-		profilePath := ProfileFile
-		fset := token.NewFileSet()
-
-		srcPath := files.SetSrcPath(args)
-		dir := srcPath
-		ignoreDirs := strings.Split(skipDirs, ",")
-		projectFiles, err := files.FilesForPath(dir, ignoreDirs)
-		if err != nil {
-			log.Printf("could not retrieve files for path %v %v", dir, err)
-			os.Exit(1)
-		}
+var (
+	dryRun                 bool
+	smallPackageStatements int64
+	// checkInitCmd represents the checkInit command
+	checkInitCmd = &cobra.Command{
+		Use:   "init",
+		Short: "Create a new config file using current coverage",
+		Long: `Create a new configuration file for gocheckcov which lists all of packages in the specified path and ` +
+			`sets the minimum converage percentage for each to the current coverage percentage for that package. ` +
+			`If the configuration file exists the packages it doesn't cover yet are added to it.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := runCheckInitCommand(args)
+			if err != nil {
+				os.Exit(1)
+			}
+		},
+	}
+)
 
-		goSrc := filepath.Join(build.Default.GOPATH, "src")
+func runCheckInitCommand(args []string) error {
+	if verbose {
+		log.SetLevel(log.DebugLevel)
+	}
 
-		packageToFunctions, err := analyzer.MapPackagesToFunctions(profilePath, projectFiles, fset, goSrc)
-		if err != nil {
-			log.Print(err)
-			os.Exit(1)
-		}
+	path, existing, cfg, err := readInitConfig()
+	if err != nil {
+		return err
+	}
 
-		pc := analyzer.NewPackageCoverages(packageToFunctions)
+	a, err := analyzeSource(args)
+	if err != nil {
+		return err
+	}
 
-		configFile := config.ConfigFile{}
-		for pkg := range packageToFunctions {
-			cov, ok := pc.Coverage(pkg)
-			if !ok {
-				log.Printf("could not get coverage for package %v", pkg)
-				os.Exit(1)
-			}
-			cfgPkg := config.ConfigPackage{MinCoveragePercentage: cov.CoveragePercent, Name: pkg}
-			configFile.Packages = append(configFile.Packages, cfgPkg)
-		}
+	for _, e := range a.staleErrs {
+		log.Print(e)
+	}
+
+	out, added, err := config.Init(existing, packageCoverageList(a, cfg), smallPackageStatements)
+	if err != nil {
+		log.Printf("could not create config file %v %v", path, err)
+		return err
+	}
+
+	return writeInitConfig(path, existing, out, len(added))
+}
+
+// readInitConfig returns the path of the config file init creates or updates,
+// its content and the config it holds, which are empty if it doesn't exist
+func readInitConfig() (string, []byte, config.ConfigFile, error) {
+	path := configFile
+	if path == "" {
+		path = config.DefaultConfigPath
+	}
+
+	existing, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("could not read config file %v", err)
+		return "", nil, config.ConfigFile{}, err
+	}
 
-		configContent, err := yaml.Marshal(configFile)
-		if err != nil {
-			log.Printf("couldn't marshal config file %v", err)
-			os.Exit(1)
+	cfg, err := config.ParseConfigFile(existing)
+	if err != nil {
+		log.Printf("could not parse config file %v %v", path, err)
+		return "", nil, config.ConfigFile{}, err
+	}
+
+	return path, existing, cfg, nil
+}
+
+// packageCoverageList returns the coverage of each package of a with cfg
+func packageCoverageList(a analysis, cfg config.ConfigFile) []config.PackageCoverage {
+	pc := packageCoverages(a.packageToFunctions, cfg)
+	packages := make([]config.PackageCoverage, 0, len(a.packageToFunctions))
+
+	for pkg := range a.packageToFunctions {
+		cov, ok := pc.Coverage(pkg)
+		if !ok {
+			continue
 		}
-		if err := ioutil.WriteFile("config.yaml", configContent, 0644); err != nil {
-			log.Printf("could not read config file %v %v", configFile, err)
-			os.Exit(1)
+
+		packages = append(packages, config.PackageCoverage{
+			Name:            pkg,
+			CoveragePercent: cov.CoveragePercent,
+			StatementCount:  cov.StatementCount,
+		})
+	}
+
+	return packages
+}
+
+// writeInitConfig writes out to path unless it is the same as existing, with
+// --dry-run it prints the diff instead
+func writeInitConfig(path string, existing, out []byte, added int) error {
+	if dryRun {
+		if d := diff.Unified(path, path, existing, out); d != "" {
+			fmt.Print(d)
+		} else {
+			fmt.Printf("%v is up to date\n", path)
 		}
-	},
+
+		return nil
+	}
+
+	if string(out) == string(existing) {
+		fmt.Printf("%v is up to date\n", path)
+		return nil
+	}
+
+	if err := ioutil.WriteFile(path, out, 0644); err != nil {
+		log.Printf("could not write config file %v %v", path, err)
+		return err
+	}
+
+	fmt.Printf("added %v packages to %v\n", added, path)
+
+	return nil
+}
+
+// packageCoverages returns the coverage of each package the way check
+// computes it with cfg: generated files and files of rules with
+// exclude_from_package don't count towards their package
+func packageCoverages(
+	packageToFunctions map[string][]profile.FunctionCoverage,
+	cfg config.ConfigFile,
+) *analyzer.PackageCoverages {
+	if !cfg.IncludeGenerated {
+		packageToFunctions, _ = analyzer.ExcludeGenerated(packageToFunctions)
+	}
+
+	rest := make(map[string][]profile.FunctionCoverage, len(packageToFunctions))
+	for pkg, functions := range packageToFunctions {
+		rest[pkg], _ = reporter.SplitRuleFiles(functions, &cfg)
+	}
+
+	return analyzer.NewPackageCoverages(rest)
 }
 
 func init() {
 	checkCmd.AddCommand(checkInitCmd)
 
-	checkInitCmd.Flags().StringVarP(&ProfileFile, "profile-file", "p", "", "path to coverage profile file")
+	checkInitCmd.Flags().StringSliceVarP(
+		&ProfileFiles,
+		"profile-file",
+		"p",
+		nil,
+		"path or glob of coverage profile files, can be repeated to merge multiple profiles",
+	)
 
 	if err := checkInitCmd.MarkFlagRequired("profile-file"); err != nil {
 		log.Print(err)
 		os.Exit(1)
 	}
+
+	checkInitCmd.Flags().StringVarP(
+		&configFile,
+		"config-file",
+		"c",
+		"",
+		"path to the configuration file to create or add to (defaults to "+config.DefaultConfigPath+")",
+	)
+
+	checkInitCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print a diff of the changes instead of writing them")
+
+	checkInitCmd.Flags().Int64Var(
+		&smallPackageStatements,
+		"small-package-statements",
+		0,
+		"leave packages with fewer statements out of the list, the global minimum is set to cover them instead",
+	)
 }
@@ -0,0 +1,207 @@
+// Copyright © 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/cvgw/gocheckcov/pkg/coverage/config"
+	"github.com/cvgw/gocheckcov/pkg/coverage/parser/profile"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ratchetMargin   float64
+	checkRatchetCmd = &cobra.Command{
+		Use:   "ratchet",
+		Short: "Raise the minimum coverage in the config file to the current coverage",
+		Long: `Raise the min_coverage_percentage of each package entry and the global minimum of the configuration ` +
+			`file to the current coverage of the packages they apply to, less an optional margin. Without ` +
+			`--config-file each threshold is raised in the config file it comes from. Thresholds are never ` +
+			`lowered, and comments and ordering in the files are kept.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := runCheckRatchetCommand(args, cmd.Flags().Changed("margin"))
+			if err != nil {
+				os.Exit(1)
+			}
+		},
+	}
+)
+
+func runCheckRatchetCommand(args []string, marginSet bool) error {
+	if verbose {
+		log.SetLevel(log.DebugLevel)
+	}
+
+	a, err := analyzeSource(args)
+	if err != nil {
+		return err
+	}
+
+	targets, err := ratchetTargets(a, marginSet)
+	if err != nil {
+		return err
+	}
+
+	if len(targets) == 0 {
+		err := fmt.Errorf("no config file applies to %v", args)
+		log.Printf("%v, create one with check init", err)
+
+		return err
+	}
+
+	paths, edits, err := ratchetFiles(targets)
+	if err != nil {
+		return err
+	}
+
+	for i, p := range paths {
+		if edits[i] == nil {
+			continue
+		}
+
+		if err := ioutil.WriteFile(p, edits[i], 0644); err != nil {
+			log.Printf("could not write config file %v %v", p, err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ratchetFiles returns the paths of the config files of targets in order and
+// their content with the thresholds raised, which is nil for those left as
+// they are. Every file is raised before any is written.
+func ratchetFiles(targets map[string]map[int]float64) ([]string, [][]byte, error) {
+	paths := make([]string, 0, len(targets))
+	for p := range targets {
+		paths = append(paths, p)
+	}
+
+	sort.Strings(paths)
+
+	edits := make([][]byte, len(paths))
+
+	for i, p := range paths {
+		out, err := ratchetFile(p, targets[p])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		edits[i] = out
+	}
+
+	return paths, edits, nil
+}
+
+// ratchetTargets returns the thresholds for the entries of each config file
+// which own the rule of a package, keyed by the path of the file
+func ratchetTargets(a analysis, marginSet bool) (map[string]map[int]float64, error) {
+	configs, err := loadConfigs(a)
+	if err != nil {
+		log.Printf("could not load config %v, create one with check init", err)
+		return nil, err
+	}
+
+	targets := make(map[string]map[int]float64)
+
+	for pkg, cfg := range configs {
+		functions := map[string][]profile.FunctionCoverage{pkg: a.packageToFunctions[pkg]}
+
+		cov, ok := packageCoverages(functions, *cfg).Coverage(pkg)
+		if !ok {
+			continue
+		}
+
+		margin := cfg.RatchetMargin
+		if marginSet {
+			margin = ratchetMargin
+		}
+
+		path, entry := cfg.PackageOwner(pkg)
+		if path == "" {
+			path = configFile
+		}
+
+		if targets[path] == nil {
+			targets[path] = make(map[int]float64)
+		}
+
+		config.AddTarget(targets[path], entry, config.RatchetTarget(cov.CoveragePercent, margin))
+	}
+
+	return targets, nil
+}
+
+// ratchetFile returns the content of the config file at path with its entries
+// raised to targets, nil if none of them was raised
+func ratchetFile(path string, targets map[int]float64) ([]byte, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Printf("could not read config file %v", err)
+		return nil, err
+	}
+
+	out, changes, err := config.RaiseEntries(content, targets)
+	if err != nil {
+		log.Printf("could not ratchet config file %v %v", path, err)
+		return nil, err
+	}
+
+	if len(changes) == 0 {
+		fmt.Printf("no thresholds in %v were raised\n", path)
+		return nil, nil
+	}
+
+	for _, c := range changes {
+		fmt.Printf("raised %v\n", c)
+	}
+
+	fmt.Printf("raised %v thresholds in %v\n", len(changes), path)
+
+	return out, nil
+}
+
+func init() {
+	checkCmd.AddCommand(checkRatchetCmd)
+
+	checkRatchetCmd.Flags().StringSliceVarP(
+		&ProfileFiles,
+		"profile-file",
+		"p",
+		nil,
+		"path or glob of coverage profile files, can be repeated to merge multiple profiles",
+	)
+
+	checkRatchetCmd.Flags().StringVarP(
+		&configFile,
+		"config-file",
+		"c",
+		"",
+		"path to the configuration file to update instead of the config files of each package",
+	)
+
+	checkRatchetCmd.Flags().Float64Var(
+		&ratchetMargin,
+		"margin",
+		0,
+		"percentage points to keep thresholds below the current coverage, overrides ratchet_margin in the config",
+	)
+}
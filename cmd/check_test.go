@@ -0,0 +1,120 @@
+// Copyright 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_convertCoverDirs(t *testing.T) {
+	if err := checkCovdata(); err != nil {
+		t.Skip(err)
+	}
+
+	g := NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "gocheckcov")
+	g.Expect(err).To(BeNil())
+
+	defer os.RemoveAll(dir)
+
+	// build a small program with -cover and run it to get a GOCOVERDIR
+	src := filepath.Join(dir, "src")
+	coverDir := filepath.Join(dir, "covdata")
+
+	g.Expect(os.MkdirAll(src, 0755)).To(BeNil())
+	g.Expect(os.MkdirAll(coverDir, 0755)).To(BeNil())
+	g.Expect(ioutil.WriteFile(filepath.Join(src, "go.mod"), []byte("module example.com/app\n"), 0644)).To(BeNil())
+	g.Expect(ioutil.WriteFile(filepath.Join(src, "main.go"), []byte(`package main
+
+func main() {
+	if len("covered") > 0 {
+		return
+	}
+
+	println("not covered")
+}
+`), 0644)).To(BeNil())
+
+	build := exec.Command("go", "build", "-cover", "-o", filepath.Join(dir, "app"), ".")
+	build.Dir = src
+	build.Env = append(os.Environ(), "GO111MODULE=on")
+
+	out, err := build.CombinedOutput()
+	g.Expect(err).To(BeNil(), string(out))
+
+	run := exec.Command(filepath.Join(dir, "app"))
+	run.Env = append(os.Environ(), "GOCOVERDIR="+coverDir)
+
+	out, err = run.CombinedOutput()
+	g.Expect(err).To(BeNil(), string(out))
+
+	f, err := convertCoverDirs([]string{coverDir})
+	g.Expect(err).To(BeNil())
+
+	defer os.Remove(f.Name())
+
+	content, err := ioutil.ReadFile(f.Name())
+	g.Expect(err).To(BeNil())
+
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	g.Expect(lines[0]).To(HavePrefix("mode: "))
+	g.Expect(lines[1:]).To(ContainElement(HavePrefix("example.com/app/main.go:")))
+	g.Expect(lines[1:]).To(ContainElement(HaveSuffix(" 1 0")))
+}
+
+func Test_convertCoverDirs_invalid(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "gocheckcov")
+	g.Expect(err).To(BeNil())
+
+	defer os.RemoveAll(dir)
+
+	_, err = convertCoverDirs([]string{filepath.Join(dir, "missing")})
+	g.Expect(err).ToNot(BeNil())
+
+	file := filepath.Join(dir, "profile.out")
+	g.Expect(ioutil.WriteFile(file, []byte("mode: set\n"), 0644)).To(BeNil())
+
+	_, err = convertCoverDirs([]string{file})
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(err.Error()).To(ContainSubstring("is not a directory"))
+}
+
+func Test_checkCovdata_noGo(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "gocheckcov")
+	g.Expect(err).To(BeNil())
+
+	defer os.RemoveAll(dir)
+
+	path := os.Getenv("PATH")
+	defer os.Setenv("PATH", path)
+
+	g.Expect(os.Setenv("PATH", dir)).To(BeNil())
+
+	err = checkCovdata()
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(err.Error()).To(ContainSubstring("not on PATH"))
+}
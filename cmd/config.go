@@ -0,0 +1,207 @@
+// Copyright © 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/cvgw/gocheckcov/pkg/coverage/config"
+	"github.com/cvgw/gocheckcov/pkg/coverage/files"
+	"github.com/spf13/cobra"
+)
+
+var (
+	validateConfigFile string
+	configCmd          = &cobra.Command{
+		Use:   "config",
+		Short: "Work with configuration files",
+	}
+	configValidateCmd = &cobra.Command{
+		Use:   "validate",
+		Short: "Check configuration files for mistakes",
+		Long: `Check the configuration files which apply to the packages in the specified path. Unknown keys, ` +
+			`percentages outside of 0-100, invalid patterns and incomplete waivers are errors, package entries and ` +
+			`waivers which match no package are warnings.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := runConfigValidateCommand(args)
+			if err != nil {
+				os.Exit(1)
+			}
+		},
+	}
+)
+
+func runConfigValidateCommand(args []string) error {
+	if verbose {
+		log.SetLevel(log.DebugLevel)
+	}
+
+	srcPath := files.SetSrcPath(args)
+
+	projectFiles, err := files.FilesForPath(srcPath, strings.Split(skipDirs, ","), buildFlags())
+	if err != nil {
+		log.Printf("could not retrieve project files from path %v %v", srcPath, err)
+		return err
+	}
+
+	resolver, err := files.NewPathResolver(srcPath)
+	if err != nil {
+		log.Printf("could not resolve import paths for %v %v", srcPath, err)
+		return err
+	}
+
+	dirs, packages := packageDirs(projectFiles.Files, resolver)
+
+	paths, err := configPaths(resolver.Root, dirs)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	if len(paths) == 0 {
+		fmt.Println("no configuration files found")
+		return nil
+	}
+
+	failed, err := validateConfigs(paths, packages)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	if failed > 0 {
+		err := fmt.Errorf("%v of %v configuration files are invalid", failed, len(paths))
+		fmt.Println(err)
+
+		return err
+	}
+
+	return nil
+}
+
+// packageDirs returns the directories of projectFiles and the import paths of
+// their packages
+func packageDirs(projectFiles []string, resolver files.PathResolver) (map[string]bool, []string) {
+	dirs := make(map[string]bool)
+	packages := make([]string, 0)
+
+	for _, f := range projectFiles {
+		dir := filepath.Dir(f)
+		if dirs[dir] {
+			continue
+		}
+
+		dirs[dir] = true
+		packages = append(packages, resolver.ImportPath(dir))
+	}
+
+	return dirs, packages
+}
+
+// validateConfigs validates each config file of paths against packages and
+// prints what it finds, it returns the number of invalid files
+func validateConfigs(paths, packages []string) (int, error) {
+	failed := 0
+
+	for _, p := range paths {
+		content, err := ioutil.ReadFile(p)
+		if err != nil {
+			return 0, err
+		}
+
+		v := config.Validate(content, packages)
+
+		for _, e := range v.Errors {
+			fmt.Printf("%v: error: %v\n", p, e)
+		}
+
+		for _, w := range v.Warnings {
+			fmt.Printf("%v: warning: %v\n", p, w)
+		}
+
+		if len(v.Errors) > 0 {
+			failed++
+		} else if len(v.Warnings) == 0 {
+			fmt.Printf("%v: ok\n", p)
+		}
+	}
+
+	return failed, nil
+}
+
+// configPaths returns the config file given with --config-file, or else every
+// config file check would read for the packages in dirs
+func configPaths(root string, dirs map[string]bool) ([]string, error) {
+	if validateConfigFile != "" {
+		return []string{validateConfigFile}, nil
+	}
+
+	loader := config.NewLoader(root)
+	seen := make(map[string]bool)
+	paths := make([]string, 0)
+
+	for dir := range dirs {
+		found, err := loader.Paths(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range found {
+			if !seen[p] {
+				seen[p] = true
+				paths = append(paths, p)
+			}
+		}
+	}
+
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configValidateCmd)
+
+	configValidateCmd.Flags().StringVarP(
+		&validateConfigFile,
+		"config-file",
+		"c",
+		"",
+		"path to a configuration file to validate instead of the ones found for each package",
+	)
+
+	configValidateCmd.Flags().StringVarP(
+		&skipDirs,
+		"skip-dirs",
+		"s",
+		"vendor",
+		"command separted list of directories to skip when listing packages",
+	)
+
+	configValidateCmd.Flags().StringVar(
+		&buildTags,
+		"tags",
+		"",
+		"comma separated list of build tags to use when loading packages",
+	)
+}
@@ -18,9 +18,12 @@ import (
 	"fmt"
 	"go/token"
 	"math"
-	"path/filepath"
-	"strings"
+	"path"
+	"runtime"
+	"sort"
+	"sync"
 
+	"github.com/cvgw/gocheckcov/pkg/coverage/files"
 	"github.com/cvgw/gocheckcov/pkg/coverage/parser/goparser"
 	"github.com/cvgw/gocheckcov/pkg/coverage/parser/goparser/functions"
 	"github.com/cvgw/gocheckcov/pkg/coverage/parser/profile"
@@ -33,12 +36,71 @@ type PackageCoverages struct {
 }
 
 type coverage struct {
+	StatementCount        int64
+	ExecutedCount         int64
+	CoveragePercent       float64
+	BranchCount           int64
+	CoveredBranchCount    int64
+	BranchCoveragePercent float64
+	LineCount             int64
+	CoveredLineCount      int64
+	LineCoveragePercent   float64
+	// FunctionCount counts the functions with statements, a function is
+	// covered if any of its statements ran
+	FunctionCount           int64
+	CoveredFunctionCount    int64
+	FunctionCoveragePercent float64
+	// API counts only include exported functions and methods of exported types
+	APIStatementCount  int64
+	APIExecutedCount   int64
+	APICoveragePercent float64
+	Functions          []profile.FunctionCoverage
+	Files              []FileCoverage
+}
+
+// FileCoverage is the coverage of the functions in one source file
+type FileCoverage struct {
+	FilePath        string
 	StatementCount  int64
 	ExecutedCount   int64
 	CoveragePercent float64
 	Functions       []profile.FunctionCoverage
 }
 
+// FileCoverages returns the coverage of functions grouped by source file and
+// sorted by file path
+func FileCoverages(functions []profile.FunctionCoverage) []FileCoverage {
+	byPath := make(map[string]*FileCoverage)
+	paths := make([]string, 0)
+
+	for _, function := range functions {
+		filePath := function.Function.SrcPath
+
+		fc, ok := byPath[filePath]
+		if !ok {
+			fc = &FileCoverage{FilePath: filePath}
+			byPath[filePath] = fc
+			paths = append(paths, filePath)
+		}
+
+		fc.StatementCount += function.StatementCount
+		fc.ExecutedCount += function.CoveredCount
+		fc.Functions = append(fc.Functions, function)
+	}
+
+	sort.Strings(paths)
+
+	files := make([]FileCoverage, 0, len(paths))
+
+	for _, filePath := range paths {
+		fc := byPath[filePath]
+		fc.CoveragePercent = Percent(fc.ExecutedCount, fc.StatementCount)
+		files = append(files, *fc)
+	}
+
+	return files
+}
+
 func (p *PackageCoverages) Coverage(pkg string) (coverage, bool) {
 	cov, ok := p.coverages[pkg]
 	return cov, ok
@@ -52,24 +114,62 @@ func NewPackageCoverages(packagesToFunctions map[string][]profile.FunctionCovera
 
 		var executedCount int64
 
+		var branchCount int64
+
+		var coveredBranchCount int64
+
+		var apiStatementCount int64
+
+		var apiExecutedCount int64
+
+		var lineCount int64
+
+		var coveredLineCount int64
+
+		var functionCount int64
+
+		var coveredFunctionCount int64
+
 		for _, function := range functions {
 			statementCount += function.StatementCount
 			executedCount += function.CoveredCount
-		}
+			branchCount += function.BranchCount
+			coveredBranchCount += function.CoveredBranchCount
+			lineCount += function.LineCount
+			coveredLineCount += function.CoveredLineCount
+
+			if function.StatementCount > 0 {
+				functionCount++
 
-		var covPer float64
+				if function.CoveredCount > 0 {
+					coveredFunctionCount++
+				}
+			}
 
-		if executedCount == 0 && statementCount == 0 {
-			covPer = 100
-		} else {
-			covPer = math.Floor((float64(executedCount)/float64(statementCount))*10000) / 100
+			if function.Function.Exported {
+				apiStatementCount += function.StatementCount
+				apiExecutedCount += function.CoveredCount
+			}
 		}
 
 		c := coverage{
-			StatementCount:  statementCount,
-			ExecutedCount:   executedCount,
-			CoveragePercent: covPer,
-			Functions:       functions,
+			StatementCount:          statementCount,
+			ExecutedCount:           executedCount,
+			CoveragePercent:         Percent(executedCount, statementCount),
+			BranchCount:             branchCount,
+			CoveredBranchCount:      coveredBranchCount,
+			BranchCoveragePercent:   Percent(coveredBranchCount, branchCount),
+			LineCount:               lineCount,
+			CoveredLineCount:        coveredLineCount,
+			LineCoveragePercent:     Percent(coveredLineCount, lineCount),
+			FunctionCount:           functionCount,
+			CoveredFunctionCount:    coveredFunctionCount,
+			FunctionCoveragePercent: Percent(coveredFunctionCount, functionCount),
+			APIStatementCount:       apiStatementCount,
+			APIExecutedCount:        apiExecutedCount,
+			APICoveragePercent:      Percent(apiExecutedCount, apiStatementCount),
+			Functions:               functions,
+			Files:                   FileCoverages(functions),
 		}
 		pkgToCoverage[pkg] = c
 	}
@@ -79,16 +179,28 @@ func NewPackageCoverages(packagesToFunctions map[string][]profile.FunctionCovera
 	}
 }
 
+// Percent returns covered as a percentage of total rounded down to two decimal
+// places. Nothing to cover counts as fully covered.
+func Percent(covered, total int64) float64 {
+	if covered == 0 && total == 0 {
+		return 100
+	}
+
+	return math.Floor((float64(covered)/float64(total))*10000) / 100
+}
+
+// MapPackagesToFunctions returns the coverage of the functions in projectFiles
+// grouped by package. Files whose profile does not match their current source
+// are still analyzed and are returned as StaleProfileErrors.
 func MapPackagesToFunctions(
-	filePath string,
+	profilePaths []string,
 	projectFiles []string,
 	fset *token.FileSet,
-	goSrc string,
-) (map[string][]profile.FunctionCoverage, error) {
-	profiles, err := cover.ParseProfiles(filePath)
+	resolver files.PathResolver,
+) (map[string][]profile.FunctionCoverage, []error, error) {
+	profiles, err := profile.ParseProfiles(profilePaths)
 	if err != nil {
-		e := fmt.Errorf("could not parse profiles from %v %v", filePath, err)
-		return nil, e
+		return nil, nil, err
 	}
 
 	filePathToProfileMap := make(map[string]*cover.Profile)
@@ -96,39 +208,170 @@ func MapPackagesToFunctions(
 		filePathToProfileMap[prof.FileName] = prof
 	}
 
+	results := make([]fileResult, len(projectFiles))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < runtime.GOMAXPROCS(0); w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				results[i] = analyzeFile(projectFiles[i], fset, resolver, filePathToProfileMap)
+			}
+		}()
+	}
+
+	for i := range projectFiles {
+		jobs <- i
+	}
+
+	close(jobs)
+	wg.Wait()
+
 	packageToFunctions := make(map[string][]profile.FunctionCoverage)
 
-	for _, filePath := range projectFiles {
-		node, err := goparser.NodeFromFilePath(filePath, goSrc, fset)
-		if err != nil {
-			e := fmt.Errorf("could not retrieve node from filepath %v", err)
-			return nil, e
+	var staleErrs []error
+
+	// results are collected in the order of projectFiles so the output does
+	// not depend on the order the workers finished in
+	for _, r := range results {
+		if r.err != nil {
+			return nil, nil, r.err
+		}
+
+		if r.staleErr != nil {
+			staleErrs = append(staleErrs, r.staleErr)
+		}
+
+		packageToFunctions[r.pkg] = append(packageToFunctions[r.pkg], r.coverages...)
+	}
+
+	log.Debugf("map of packages to functions %v", packageToFunctions)
+
+	return packageToFunctions, staleErrs, nil
+}
+
+type fileResult struct {
+	pkg       string
+	coverages []profile.FunctionCoverage
+	staleErr  error
+	err       error
+}
+
+func analyzeFile(
+	diskPath string,
+	fset *token.FileSet,
+	resolver files.PathResolver,
+	filePathToProfileMap map[string]*cover.Profile,
+) fileResult {
+	filePath := resolver.ImportPath(diskPath)
+
+	node, src, err := goparser.ParseFile(diskPath, fset)
+	if err != nil {
+		return fileResult{err: fmt.Errorf("could not retrieve node from filepath %v", err)}
+	}
+
+	functions, err := functions.CollectFunctions(node, fset, filePath)
+	if err != nil {
+		return fileResult{err: fmt.Errorf("could not collect functions for filepath %v %v", filePath, err)}
+	}
+
+	log.Debugf("functions for file %v %v", filePath, functions)
+
+	p := profile.Parser{FilePath: filePath, Fset: fset}
+
+	if prof, ok := filePathToProfileMap[filePath]; ok {
+		p.Profile = prof
+	}
+
+	r := fileResult{pkg: path.Dir(filePath)}
+
+	if err := p.Validate(src, functions); err != nil {
+		log.Debug(err)
+		r.staleErr = err
+	}
+
+	r.coverages = p.RecordFunctionCoverage(functions)
+
+	return r
+}
+
+// Generated summarizes the generated code left out of the analysis
+type Generated struct {
+	Files      int
+	Statements int64
+}
+
+// ExcludeGenerated returns packageToFunctions without the functions of
+// generated files. Packages made up only of generated files are left out.
+func ExcludeGenerated(
+	packageToFunctions map[string][]profile.FunctionCoverage,
+) (map[string][]profile.FunctionCoverage, Generated) {
+	out := make(map[string][]profile.FunctionCoverage)
+	files := make(map[string]bool)
+
+	var skipped Generated
+
+	for pkg, functions := range packageToFunctions {
+		kept := make([]profile.FunctionCoverage, 0, len(functions))
+
+		for _, function := range functions {
+			if !function.Function.Generated {
+				kept = append(kept, function)
+				continue
+			}
+
+			files[function.Function.SrcPath] = true
+			skipped.Statements += function.StatementCount
 		}
 
-		functions, err := functions.CollectFunctions(node, fset, filePath)
-		if err != nil {
-			e := fmt.Errorf("could not collect functions for filepath %v %v", filePath, err)
-			return nil, e
+		if len(kept) == 0 && len(functions) > 0 {
+			continue
 		}
 
-		log.Debugf("functions for file %v %v", filePath, functions)
-		pkg := strings.TrimPrefix(filePath, fmt.Sprintf("%s/", goSrc))
-		pkg = filepath.Dir(pkg)
+		out[pkg] = kept
+	}
+
+	skipped.Files = len(files)
+
+	return out, skipped
+}
 
-		var funcCoverages []profile.FunctionCoverage
+// MapPackagesToFiles returns the coverage of each file in the profiles at
+// profilePaths grouped by package. The coverage is computed from the profile
+// blocks alone, so each FunctionCoverage covers a whole file and no source is
+// read.
+func MapPackagesToFiles(profilePaths []string) (map[string][]profile.FunctionCoverage, error) {
+	profiles, err := profile.ParseProfiles(profilePaths)
+	if err != nil {
+		return nil, err
+	}
 
-		p := profile.Parser{FilePath: filePath, Fset: fset}
+	packageToFiles := make(map[string][]profile.FunctionCoverage)
 
-		if prof, ok := filePathToProfileMap[filePath]; ok {
-			p.Profile = prof
+	for _, prof := range profiles {
+		fc := profile.FunctionCoverage{
+			Name:     prof.FileName,
+			Function: functions.Function{Name: prof.FileName, SrcPath: prof.FileName},
+			Profile:  prof,
 		}
 
-		funcCoverages = p.RecordFunctionCoverage(functions)
+		for _, block := range prof.Blocks {
+			fc.StatementCount += int64(block.NumStmt)
+			if block.Count > 0 {
+				fc.CoveredCount += int64(block.NumStmt)
+			}
+		}
 
-		packageToFunctions[pkg] = append(packageToFunctions[pkg], funcCoverages...)
+		pkg := path.Dir(prof.FileName)
+		packageToFiles[pkg] = append(packageToFiles[pkg], fc)
 	}
 
-	log.Debugf("map of packages to functions %v", packageToFunctions)
+	log.Debugf("map of packages to files %v", packageToFiles)
 
-	return packageToFunctions, nil
+	return packageToFiles, nil
 }
@@ -18,10 +18,13 @@ import (
 	"fmt"
 	"go/token"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/cvgw/gocheckcov/pkg/coverage/files"
+	"github.com/cvgw/gocheckcov/pkg/coverage/parser/goparser/functions"
 	"github.com/cvgw/gocheckcov/pkg/coverage/parser/profile"
 	. "github.com/onsi/gomega"
 )
@@ -72,6 +75,121 @@ func Test_PackageCoverages_Coverage(t *testing.T) {
 	cov, ok := p.Coverage("github.com/foo/bar/pkg/baz")
 	g.Expect(ok).To(BeTrue())
 	g.Expect(cov.CoveragePercent).To(Equal(float64(100)))
+	g.Expect(cov.BranchCoveragePercent).To(Equal(float64(100)))
+}
+
+func Test_PackageCoverages_APICoverage(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	pkgToFuncs := map[string][]profile.FunctionCoverage{
+		"github.com/foo/bar/pkg/baz": []profile.FunctionCoverage{
+			{StatementCount: 4, CoveredCount: 3, Function: functions.Function{Exported: true}},
+			{StatementCount: 6, CoveredCount: 0},
+		},
+	}
+
+	p := NewPackageCoverages(pkgToFuncs)
+	cov, ok := p.Coverage("github.com/foo/bar/pkg/baz")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(cov.CoveragePercent).To(Equal(float64(30)))
+	g.Expect(cov.APIStatementCount).To(Equal(int64(4)))
+	g.Expect(cov.APIExecutedCount).To(Equal(int64(3)))
+	g.Expect(cov.APICoveragePercent).To(Equal(float64(75)))
+}
+
+func Test_FileCoverages(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	a1 := profile.FunctionCoverage{StatementCount: 4, CoveredCount: 4, Function: functions.Function{SrcPath: "foo/a.go"}}
+	b := profile.FunctionCoverage{StatementCount: 3, CoveredCount: 1, Function: functions.Function{SrcPath: "foo/b.go"}}
+	a2 := profile.FunctionCoverage{StatementCount: 4, Function: functions.Function{SrcPath: "foo/a.go"}}
+
+	files := FileCoverages([]profile.FunctionCoverage{b, a1, a2})
+	g.Expect(files).To(Equal([]FileCoverage{
+		{
+			FilePath:        "foo/a.go",
+			StatementCount:  8,
+			ExecutedCount:   4,
+			CoveragePercent: 50,
+			Functions:       []profile.FunctionCoverage{a1, a2},
+		},
+		{
+			FilePath:        "foo/b.go",
+			StatementCount:  3,
+			ExecutedCount:   1,
+			CoveragePercent: 33.33,
+			Functions:       []profile.FunctionCoverage{b},
+		},
+	}))
+}
+
+func Test_ExcludeGenerated(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	mock := profile.FunctionCoverage{
+		StatementCount: 5,
+		Function:       functions.Function{SrcPath: "foo/mocks/mock.go", Generated: true},
+	}
+	pb := profile.FunctionCoverage{
+		StatementCount: 3,
+		Function:       functions.Function{SrcPath: "foo/bar/bar.pb.go", Generated: true},
+	}
+	bar := profile.FunctionCoverage{StatementCount: 2, Function: functions.Function{SrcPath: "foo/bar/bar.go"}}
+
+	pkgToFuncs := map[string][]profile.FunctionCoverage{
+		"foo/mocks": {mock, mock},
+		"foo/bar":   {pb, bar},
+		"foo/empty": {},
+	}
+
+	out, generated := ExcludeGenerated(pkgToFuncs)
+	g.Expect(out).To(Equal(map[string][]profile.FunctionCoverage{
+		"foo/bar":   {bar},
+		"foo/empty": {},
+	}))
+	g.Expect(generated).To(Equal(Generated{Files: 2, Statements: 13}))
+}
+
+func Test_PackageCoverages_BranchCoverage(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	pkgToFuncs := map[string][]profile.FunctionCoverage{
+		"github.com/foo/bar/pkg/baz": []profile.FunctionCoverage{
+			{StatementCount: 4, CoveredCount: 4, BranchCount: 2, CoveredBranchCount: 1},
+			{StatementCount: 2, CoveredCount: 1, BranchCount: 1},
+		},
+	}
+
+	p := NewPackageCoverages(pkgToFuncs)
+	cov, ok := p.Coverage("github.com/foo/bar/pkg/baz")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(cov.BranchCount).To(Equal(int64(3)))
+	g.Expect(cov.CoveredBranchCount).To(Equal(int64(1)))
+	g.Expect(cov.BranchCoveragePercent).To(Equal(33.33))
+}
+
+func Test_PackageCoverages_LineAndFunctionCoverage(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	pkgToFuncs := map[string][]profile.FunctionCoverage{
+		"github.com/foo/bar/pkg/baz": []profile.FunctionCoverage{
+			{StatementCount: 4, CoveredCount: 1, LineCount: 3, CoveredLineCount: 1},
+			{StatementCount: 2, CoveredCount: 0, LineCount: 2},
+			{StatementCount: 1, CoveredCount: 1, LineCount: 1, CoveredLineCount: 1},
+			// functions without statements can't be covered and aren't counted
+			{},
+		},
+	}
+
+	p := NewPackageCoverages(pkgToFuncs)
+	cov, ok := p.Coverage("github.com/foo/bar/pkg/baz")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(cov.LineCount).To(Equal(int64(6)))
+	g.Expect(cov.CoveredLineCount).To(Equal(int64(2)))
+	g.Expect(cov.LineCoveragePercent).To(Equal(33.33))
+	g.Expect(cov.FunctionCount).To(Equal(int64(3)))
+	g.Expect(cov.CoveredFunctionCount).To(Equal(int64(2)))
+	g.Expect(cov.FunctionCoveragePercent).To(Equal(66.66))
 }
 
 func Test_MapPackagesToFunctions(t *testing.T) {
@@ -170,7 +288,9 @@ func Meow(x, y int) bool {
 
 			fset := token.NewFileSet()
 
-			res, err := MapPackagesToFunctions(tc.covPath, []string{tc.srcPath}, fset, "")
+			resolver := files.PathResolver{Root: "/"}
+
+			res, _, err := MapPackagesToFunctions([]string{tc.covPath}, []string{tc.srcPath}, fset, resolver)
 			if tc.expectErr {
 				g.Expect(err).ToNot(BeNil())
 			} else {
@@ -182,3 +302,191 @@ func Meow(x, y int) bool {
 		})
 	}
 }
+
+func Test_MapPackagesToFunctions_module(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Errorf("could not create temp dir")
+		t.FailNow()
+	}
+
+	srcContent := `
+package foo
+
+func Meow(x, y int) bool {
+  if x > y {
+	  return true
+  }
+	return false
+}
+`
+	srcPath := filepath.Join(dir, "src.go")
+
+	err = ioutil.WriteFile(srcPath, []byte(srcContent), 0644)
+	if err != nil {
+		t.Errorf("could not write to temp file %v", err)
+		t.FailNow()
+	}
+
+	profilePath := filepath.Join(dir, "profile.out")
+	coverageContent := "mode: set\n" +
+		"example.com/svc/src.go:4.26,5.12 1 1\n" +
+		"example.com/svc/src.go:5.12,7.4 1 0\n" +
+		"example.com/svc/src.go:8.2,8.14 1 1\n"
+
+	err = ioutil.WriteFile(profilePath, []byte(coverageContent), 0644)
+	if err != nil {
+		t.Errorf("could not write to temp file %v", err)
+		t.FailNow()
+	}
+
+	resolver := files.PathResolver{Root: dir, ImportRoot: "example.com/svc"}
+
+	res, staleErrs, err := MapPackagesToFunctions([]string{profilePath}, []string{srcPath}, token.NewFileSet(), resolver)
+	g.Expect(err).To(BeNil())
+	g.Expect(staleErrs).To(BeEmpty())
+	g.Expect(res).To(HaveKey("example.com/svc"))
+	g.Expect(res["example.com/svc"]).To(HaveLen(1))
+
+	fc := res["example.com/svc"][0]
+	g.Expect(fc.Function.SrcPath).To(Equal("example.com/svc/src.go"))
+	g.Expect(fc.StatementCount).To(Equal(int64(3)))
+	g.Expect(fc.CoveredCount).To(Equal(int64(2)))
+}
+
+func Test_MapPackagesToFunctions_stale_profile(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Errorf("could not create temp dir")
+		t.FailNow()
+	}
+
+	srcContent := `
+package foo
+
+func Meow() bool {
+	return false
+}
+`
+	srcPath := filepath.Join(dir, "src.go")
+
+	err = ioutil.WriteFile(srcPath, []byte(srcContent), 0644)
+	if err != nil {
+		t.Errorf("could not write to temp file %v", err)
+		t.FailNow()
+	}
+
+	profilePath := filepath.Join(dir, "profile.out")
+	coverageContent := "mode: set\n" +
+		"example.com/svc/src.go:4.18,5.14 1 1\n" +
+		"example.com/svc/src.go:12.2,14.14 1 1\n"
+
+	err = ioutil.WriteFile(profilePath, []byte(coverageContent), 0644)
+	if err != nil {
+		t.Errorf("could not write to temp file %v", err)
+		t.FailNow()
+	}
+
+	resolver := files.PathResolver{Root: dir, ImportRoot: "example.com/svc"}
+
+	res, staleErrs, err := MapPackagesToFunctions([]string{profilePath}, []string{srcPath}, token.NewFileSet(), resolver)
+	g.Expect(err).To(BeNil())
+	g.Expect(res).To(HaveKey("example.com/svc"))
+	g.Expect(staleErrs).To(HaveLen(1))
+	g.Expect(staleErrs[0].Error()).To(ContainSubstring("profile out of date for file example.com/svc/src.go"))
+}
+
+func Test_MapPackagesToFiles(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Errorf("could not create temp dir")
+		t.FailNow()
+	}
+
+	profilePath := filepath.Join(dir, "profile.out")
+	coverageContent := "mode: count\n" +
+		"example.com/svc/a.go:4.26,5.12 2 3\n" +
+		"example.com/svc/a.go:5.12,7.4 1 0\n" +
+		"example.com/svc/b.go:8.2,8.14 1 1\n" +
+		"example.com/svc/sub/c.go:8.2,8.14 4 0\n"
+
+	err = ioutil.WriteFile(profilePath, []byte(coverageContent), 0644)
+	if err != nil {
+		t.Errorf("could not write to temp file %v", err)
+		t.FailNow()
+	}
+
+	res, err := MapPackagesToFiles([]string{profilePath})
+	g.Expect(err).To(BeNil())
+	g.Expect(res).To(HaveLen(2))
+	g.Expect(res["example.com/svc"]).To(HaveLen(2))
+	g.Expect(res["example.com/svc"][0].Name).To(Equal("example.com/svc/a.go"))
+	g.Expect(res["example.com/svc"][0].StatementCount).To(Equal(int64(3)))
+	g.Expect(res["example.com/svc"][0].CoveredCount).To(Equal(int64(2)))
+
+	pc := NewPackageCoverages(res)
+	cov, ok := pc.Coverage("example.com/svc/sub")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(cov.CoveragePercent).To(Equal(float64(0)))
+
+	_, err = MapPackagesToFiles([]string{filepath.Join(dir, "missing.out")})
+	g.Expect(err).ToNot(BeNil())
+}
+
+func Test_MapPackagesToFunctions_deterministic(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Errorf("could not create temp dir")
+		t.FailNow()
+	}
+
+	var projectFiles []string
+
+	for i := 0; i < 32; i++ {
+		pkgDir := filepath.Join(dir, fmt.Sprintf("pkg%v", i%3))
+		if err := os.MkdirAll(pkgDir, 0777); err != nil {
+			t.Errorf("could not create temp dir %v", err)
+			t.FailNow()
+		}
+
+		srcPath := filepath.Join(pkgDir, fmt.Sprintf("src%v.go", i))
+		srcContent := fmt.Sprintf("package foo\n\nfunc Meow%v() bool {\n\treturn false\n}\n", i)
+
+		if err := ioutil.WriteFile(srcPath, []byte(srcContent), 0644); err != nil {
+			t.Errorf("could not write to temp file %v", err)
+			t.FailNow()
+		}
+
+		projectFiles = append(projectFiles, srcPath)
+	}
+
+	profilePath := filepath.Join(dir, "profile.out")
+
+	err = ioutil.WriteFile(profilePath, []byte("mode: set\n"), 0644)
+	if err != nil {
+		t.Errorf("could not write to temp file %v", err)
+		t.FailNow()
+	}
+
+	resolver := files.PathResolver{Root: dir, ImportRoot: "example.com/svc"}
+
+	first, _, err := MapPackagesToFunctions([]string{profilePath}, projectFiles, token.NewFileSet(), resolver)
+	g.Expect(err).To(BeNil())
+	g.Expect(first).To(HaveLen(3))
+
+	for i, fc := range first["example.com/svc/pkg0"] {
+		g.Expect(fc.Name).To(Equal(fmt.Sprintf("Meow%v", i*3)))
+	}
+
+	second, _, err := MapPackagesToFunctions([]string{profilePath}, projectFiles, token.NewFileSet(), resolver)
+	g.Expect(err).To(BeNil())
+	g.Expect(second).To(Equal(first))
+}
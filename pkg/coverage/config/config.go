@@ -15,19 +15,22 @@
 package config
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
+
+	"gopkg.in/yaml.v2"
 )
 
 const (
-	defaultConfigPath = ".gocheckcov-config.yml"
+	DefaultConfigPath = ".gocheckcov-config.yml"
 )
 
 func GetConfigFile(configPath string) ([]byte, error) {
 	var cfContent []byte
 
 	if configPath == "" {
-		configPath = defaultConfigPath
+		configPath = DefaultConfigPath
 	}
 
 	_, err := os.Stat(configPath)
@@ -36,7 +39,7 @@ func GetConfigFile(configPath string) ([]byte, error) {
 			return nil, err
 		}
 
-		if configPath != defaultConfigPath {
+		if configPath != DefaultConfigPath {
 			return nil, err
 		}
 	} else {
@@ -50,21 +53,199 @@ func GetConfigFile(configPath string) ([]byte, error) {
 }
 
 type ConfigFile struct {
-	MinCoveragePercentage float64         `yaml:"min_coverage_percentage"`
-	Packages              []ConfigPackage `yaml:"packages"`
+	MinCoveragePercentage         float64          `yaml:"min_coverage_percentage"`
+	MinBranchCoveragePercentage   float64          `yaml:"min_branch_coverage_percentage,omitempty"`
+	MinLineCoveragePercentage     float64          `yaml:"min_line_coverage_percentage,omitempty"`
+	MinFunctionCoveragePercentage float64          `yaml:"min_function_coverage_percentage,omitempty"`
+	MaxCRAPScore                  float64          `yaml:"max_crap_score,omitempty"`
+	MinAPICoveragePercentage      float64          `yaml:"min_api_coverage_percentage,omitempty"`
+	MaxUncoveredStatements        *int64           `yaml:"max_uncovered_statements,omitempty"`
+	MinCoveredStatements          int64            `yaml:"min_covered_statements,omitempty"`
+	IncludeGenerated              bool             `yaml:"include_generated,omitempty"`
+	RatchetMargin                 float64          `yaml:"ratchet_margin,omitempty"`
+	Packages                      []ConfigPackage  `yaml:"packages"`
+	Files                         []ConfigFileRule `yaml:"files,omitempty"`
+	Waivers                       []Waiver         `yaml:"waivers,omitempty"`
+
+	// matchers and fileMatchers hold the compiled names of Packages and Files
+	matchers     []packageMatcher
+	fileMatchers []packageMatcher
+	// packageOrigins, fileOrigins, waiverOrigins and sources are set when the
+	// config was merged from several files by a Loader
+	packageOrigins []origin
+	fileOrigins    []origin
+	waiverOrigins  []origin
+	sources        []string
+}
+
+func ParseConfigFile(content []byte) (ConfigFile, error) {
+	cfg := ConfigFile{}
+	if err := yaml.Unmarshal(content, &cfg); err != nil {
+		return ConfigFile{}, err
+	}
+
+	if err := cfg.compile(); err != nil {
+		return ConfigFile{}, err
+	}
+
+	return cfg, nil
+}
+
+// compile checks the patterns of c and keeps the matchers for Packages, Files
+// and Waivers
+func (c *ConfigFile) compile() error {
+	for i := range c.Packages {
+		p := &c.Packages[i]
+
+		m, err := newPackageMatcher(p.Name)
+		if err != nil {
+			return err
+		}
+
+		c.matchers = append(c.matchers, m)
+
+		for _, f := range p.Functions {
+			fm, err := newFunctionMatcher(f.Name)
+			if err != nil {
+				return fmt.Errorf("package %v: %v", p.Name, err)
+			}
+
+			p.functionMatchers = append(p.functionMatchers, fm)
+		}
+	}
+
+	for _, f := range c.Files {
+		m, err := newPackageMatcher(f.Name)
+		if err != nil {
+			return err
+		}
+
+		c.fileMatchers = append(c.fileMatchers, m)
+	}
+
+	for i := range c.Waivers {
+		if err := c.Waivers[i].compile(); err != nil {
+			return fmt.Errorf("waivers[%v] %v", i, err)
+		}
+	}
+
+	return nil
+}
+
+// GlobalPackage returns the top level thresholds of c as the entry for pkg
+func (c ConfigFile) GlobalPackage(pkg string) ConfigPackage {
+	return ConfigPackage{
+		Name:                          pkg,
+		MinCoveragePercentage:         c.MinCoveragePercentage,
+		MinBranchCoveragePercentage:   c.MinBranchCoveragePercentage,
+		MinLineCoveragePercentage:     c.MinLineCoveragePercentage,
+		MinFunctionCoveragePercentage: c.MinFunctionCoveragePercentage,
+		MaxCRAPScore:                  c.MaxCRAPScore,
+		MinAPICoveragePercentage:      c.MinAPICoveragePercentage,
+		MaxUncoveredStatements:        c.MaxUncoveredStatements,
+		MinCoveredStatements:          c.MinCoveredStatements,
+	}
 }
 
 func (c ConfigFile) GetPackage(pkg string) (ConfigPackage, bool) {
-	for _, p := range c.Packages {
-		if p.Name == pkg {
-			return p, true
+	p, _, ok := c.MatchPackage(pkg)
+	return p, ok
+}
+
+// MatchPackage returns the most specific entry of Packages whose name matches
+// pkg along with its index. Ties go to the first entry. In a merged config
+// entries of nearer files win over those of farther files.
+func (c ConfigFile) MatchPackage(pkg string) (ConfigPackage, int, bool) {
+	matchers := c.matchers
+	if len(matchers) != len(c.Packages) {
+		names := make([]string, 0, len(c.Packages))
+		for _, p := range c.Packages {
+			names = append(names, p.Name)
 		}
+
+		matchers = compileMatchers(names)
 	}
 
-	return ConfigPackage{}, false
+	best := bestMatch(matchers, originLayers(c.packageOrigins), pkg)
+	if best == -1 {
+		return ConfigPackage{}, -1, false
+	}
+
+	return c.Packages[best], best, true
+}
+
+// MatchFile returns the most specific entry of Files whose name matches
+// filePath along with its index. Ties go to the first entry.
+func (c ConfigFile) MatchFile(filePath string) (ConfigFileRule, int, bool) {
+	matchers := c.fileMatchers
+	if len(matchers) != len(c.Files) {
+		names := make([]string, 0, len(c.Files))
+		for _, f := range c.Files {
+			names = append(names, f.Name)
+		}
+
+		matchers = compileMatchers(names)
+	}
+
+	best := bestMatch(matchers, originLayers(c.fileOrigins), filePath)
+	if best == -1 {
+		return ConfigFileRule{}, -1, false
+	}
+
+	return c.Files[best], best, true
 }
 
 type ConfigPackage struct {
+	Name                          string           `yaml:"name"`
+	MinCoveragePercentage         float64          `yaml:"min_coverage_percentage"`
+	MinBranchCoveragePercentage   float64          `yaml:"min_branch_coverage_percentage,omitempty"`
+	MinLineCoveragePercentage     float64          `yaml:"min_line_coverage_percentage,omitempty"`
+	MinFunctionCoveragePercentage float64          `yaml:"min_function_coverage_percentage,omitempty"`
+	MaxCRAPScore                  float64          `yaml:"max_crap_score,omitempty"`
+	MinAPICoveragePercentage      float64          `yaml:"min_api_coverage_percentage,omitempty"`
+	MaxUncoveredStatements        *int64           `yaml:"max_uncovered_statements,omitempty"`
+	MinCoveredStatements          int64            `yaml:"min_covered_statements,omitempty"`
+	Functions                     []ConfigFunction `yaml:"functions,omitempty"`
+
+	// functionMatchers holds the compiled names of Functions
+	functionMatchers []packageMatcher
+}
+
+// MatchFunction returns the most specific entry of Functions whose name
+// matches the function name along with its index. Names are matched like
+// package names, except that the * of a leading pointer receiver is not a
+// glob, so (*Client).Close matches only itself.
+func (p ConfigPackage) MatchFunction(name string) (ConfigFunction, int, bool) {
+	matchers := p.functionMatchers
+	if len(matchers) != len(p.Functions) {
+		names := make([]string, 0, len(p.Functions))
+		for _, f := range p.Functions {
+			names = append(names, f.Name)
+		}
+
+		matchers = compileFunctionMatchers(names)
+	}
+
+	best := bestMatch(matchers, nil, name)
+	if best == -1 {
+		return ConfigFunction{}, -1, false
+	}
+
+	return p.Functions[best], best, true
+}
+
+// ConfigFileRule sets a minimum coverage for the source files which match Name.
+// Matching files are checked on their own in addition to counting towards the
+// coverage of their package, unless ExcludeFromPackage is set.
+type ConfigFileRule struct {
+	Name                  string  `yaml:"name"`
+	MinCoveragePercentage float64 `yaml:"min_coverage_percentage"`
+	ExcludeFromPackage    bool    `yaml:"exclude_from_package,omitempty"`
+}
+
+// ConfigFunction sets a minimum coverage for the functions of a package which
+// match Name
+type ConfigFunction struct {
 	Name                  string  `yaml:"name"`
 	MinCoveragePercentage float64 `yaml:"min_coverage_percentage"`
 }
@@ -122,3 +122,161 @@ func Test_ConfigFile_GetPackage(t *testing.T) {
 	g.Expect(ok).To(BeTrue())
 	g.Expect(pkg).To(Equal(pkgs[0]))
 }
+
+func Test_ConfigFile_MatchPackage(t *testing.T) {
+	c := ConfigFile{
+		Packages: []ConfigPackage{
+			{Name: "^example\\.com/svc/.*/v[0-9]+$"},
+			{Name: "example.com/svc/internal/..."},
+			{Name: "**/handlers"},
+			{Name: "example.com/svc/internal/db"},
+			{Name: "example.com/svc/*/handlers"},
+			{Name: "example.com/svc/internal/*"},
+			{Name: "example.com/svc/internal/..."},
+		},
+	}
+
+	type testcase struct {
+		pkg         string
+		expectIndex int
+	}
+
+	testCases := map[string]testcase{
+		"no match":                        {pkg: "example.com/other", expectIndex: -1},
+		"regex":                           {pkg: "example.com/svc/api/v2", expectIndex: 0},
+		"dots match the directory itself": {pkg: "example.com/svc/internal", expectIndex: 1},
+		"dots match nested packages":      {pkg: "example.com/svc/internal/db/migrations", expectIndex: 1},
+		"exact beats glob":                {pkg: "example.com/svc/internal/db", expectIndex: 3},
+		"more literal characters win":     {pkg: "example.com/svc/internal/cache", expectIndex: 5},
+		"double star matches any prefix":  {pkg: "example.com/web/handlers", expectIndex: 2},
+		"star matches one element":        {pkg: "example.com/svc/api/handlers", expectIndex: 4},
+		"glob beats regex":                {pkg: "example.com/svc/internal/v1", expectIndex: 5},
+	}
+
+	for desc := range testCases {
+		tc := testCases[desc]
+		t.Run(desc, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			_, i, ok := c.MatchPackage(tc.pkg)
+			g.Expect(i).To(Equal(tc.expectIndex))
+			g.Expect(ok).To(Equal(tc.expectIndex != -1))
+		})
+	}
+}
+
+func Test_ConfigPackage_MatchFunction(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	p := ConfigPackage{
+		Functions: []ConfigFunction{
+			{Name: "Charge*", MinCoveragePercentage: 90},
+			{Name: "(*Client).Close", MinCoveragePercentage: 80},
+			{Name: "ChargeCard", MinCoveragePercentage: 100},
+			{Name: "^(\\(\\*Auth\\)\\.)?Verify", MinCoveragePercentage: 70},
+		},
+	}
+
+	f, i, ok := p.MatchFunction("ChargeCard")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(i).To(Equal(2))
+	g.Expect(f.MinCoveragePercentage).To(Equal(float64(100)))
+
+	_, i, _ = p.MatchFunction("ChargeRefund")
+	g.Expect(i).To(Equal(0))
+
+	_, i, _ = p.MatchFunction("(*Client).Close")
+	g.Expect(i).To(Equal(1))
+
+	_, i, _ = p.MatchFunction("(*Auth).VerifyToken")
+	g.Expect(i).To(Equal(3))
+
+	_, _, ok = p.MatchFunction("Refund")
+	g.Expect(ok).To(BeFalse())
+}
+
+func Test_ConfigPackage_MatchFunction_pointerReceiver(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c, err := ParseConfigFile([]byte(`
+packages:
+- name: example.com/svc/billing
+  functions:
+  - name: (*Client).Close
+    min_coverage_percentage: 80
+  - name: (*Client).*
+    min_coverage_percentage: 70
+`))
+	g.Expect(err).To(BeNil())
+
+	p, _, ok := c.MatchPackage("example.com/svc/billing")
+	g.Expect(ok).To(BeTrue())
+
+	_, i, _ := p.MatchFunction("(*Client).Close")
+	g.Expect(i).To(Equal(0))
+
+	_, i, _ = p.MatchFunction("(*Client).Dial")
+	g.Expect(i).To(Equal(1))
+
+	_, _, ok = p.MatchFunction("(XClient).Close")
+	g.Expect(ok).To(BeFalse())
+
+	_, _, ok = p.MatchFunction("(XClient).Dial")
+	g.Expect(ok).To(BeFalse())
+}
+
+func Test_ConfigFile_MatchFile(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c, err := ParseConfigFile([]byte(`
+files:
+- name: "**/*_table.go"
+  min_coverage_percentage: 0
+- name: example.com/svc/billing/calc.go
+  min_coverage_percentage: 100
+`))
+	g.Expect(err).To(BeNil())
+
+	f, i, ok := c.MatchFile("example.com/svc/tax/rates_table.go")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(i).To(Equal(0))
+	g.Expect(f.MinCoveragePercentage).To(Equal(float64(0)))
+
+	_, i, _ = c.MatchFile("example.com/svc/billing/calc.go")
+	g.Expect(i).To(Equal(1))
+
+	_, _, ok = c.MatchFile("example.com/svc/billing/invoice.go")
+	g.Expect(ok).To(BeFalse())
+}
+
+func Test_ParseConfigFile(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cfg, err := ParseConfigFile(nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(cfg).To(Equal(ConfigFile{}))
+
+	cfg, err = ParseConfigFile([]byte(`
+min_coverage_percentage: 10
+include_generated: true
+`))
+	g.Expect(err).To(BeNil())
+	g.Expect(cfg.MinCoveragePercentage).To(Equal(float64(10)))
+	g.Expect(cfg.IncludeGenerated).To(BeTrue())
+
+	_, err = ParseConfigFile([]byte("meow"))
+	g.Expect(err).ToNot(BeNil())
+
+	_, err = ParseConfigFile([]byte(`
+packages:
+- name: ^example.com/(svc
+`))
+	g.Expect(err).ToNot(BeNil())
+
+	_, err = ParseConfigFile([]byte(`
+packages:
+- name: example.com/svc
+  functions:
+  - name: ^Charge(
+`))
+	g.Expect(err).ToNot(BeNil())
+}
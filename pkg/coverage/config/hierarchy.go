@@ -0,0 +1,339 @@
+// Copyright © 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// origin records which config file an entry of a merged config came from.
+// Layer 0 is the file nearest to the package.
+type origin struct {
+	path  string
+	layer int
+	index int
+}
+
+// Loader finds the config files which apply to a directory and merges them.
+// Config files are read at most once.
+type Loader struct {
+	// Root is the outermost directory searched for config files, usually the
+	// module root
+	Root string
+	// Fallback is merged as the farthest config file when it is not already on
+	// the path from a directory to Root, usually the config file of the
+	// working directory
+	Fallback string
+	// ImportPath names the implicit package entry of a config file after its
+	// directory, the path of the directory is used if it is nil
+	ImportPath func(dir string) string
+
+	files map[string]*layer
+}
+
+type layer struct {
+	path string
+	raw  map[string]interface{}
+	cfg  ConfigFile
+}
+
+// NewLoader returns a Loader which searches up to root and falls back to the
+// config file of the working directory
+func NewLoader(root string) *Loader {
+	return &Loader{Root: root, Fallback: DefaultConfigPath, files: make(map[string]*layer)}
+}
+
+// Paths returns the config files in dir and each of its parents up to Root
+// followed by Fallback, nearest first. Files which don't exist are left out.
+func (l *Loader) Paths(dir string) ([]string, error) {
+	found := &pathSet{paths: make([]string, 0), seen: make(map[string]bool)}
+
+	if l.Root != "" && underRoot(l.Root, dir) {
+		for d := filepath.Clean(dir); ; d = filepath.Dir(d) {
+			if err := found.add(filepath.Join(d, DefaultConfigPath)); err != nil {
+				return nil, err
+			}
+
+			if d == filepath.Clean(l.Root) || d == filepath.Dir(d) {
+				break
+			}
+		}
+	}
+
+	if l.Fallback != "" {
+		if err := found.add(l.Fallback); err != nil {
+			return nil, err
+		}
+	}
+
+	return found.paths, nil
+}
+
+// pathSet collects the absolute paths of the files which exist, in the order
+// they are added and each once
+type pathSet struct {
+	paths []string
+	seen  map[string]bool
+}
+
+func (s *pathSet) add(p string) error {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return err
+	}
+
+	if s.seen[abs] {
+		return nil
+	}
+
+	if _, err := os.Stat(abs); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	s.seen[abs] = true
+	s.paths = append(s.paths, abs)
+
+	return nil
+}
+
+func underRoot(root, dir string) bool {
+	if !filepath.IsAbs(dir) {
+		return false
+	}
+
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return false
+	}
+
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Load returns the config for dir merged from the files returned by Paths.
+// Top level settings of nearer files win. The package and file rules of a
+// nearer file, and its top level min_coverage_percentage, win over the rules
+// of farther files, whichever is more specific, and its waivers come first.
+func (l *Loader) Load(dir string) (ConfigFile, error) {
+	paths, err := l.Paths(dir)
+	if err != nil {
+		return ConfigFile{}, err
+	}
+
+	layers := make([]*layer, 0, len(paths))
+
+	for _, p := range paths {
+		ly, err := l.read(p)
+		if err != nil {
+			return ConfigFile{}, err
+		}
+
+		layers = append(layers, ly)
+	}
+
+	return merge(layers, l.ImportPath)
+}
+
+func (l *Loader) read(p string) (*layer, error) {
+	if ly, ok := l.files[p]; ok {
+		return ly, nil
+	}
+
+	content, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+
+	ly := &layer{path: p}
+
+	if err := yaml.Unmarshal(content, &ly.raw); err != nil {
+		return nil, fmt.Errorf("could not parse config file %v %v", p, err)
+	}
+
+	if ly.cfg, err = ParseConfigFile(content); err != nil {
+		return nil, fmt.Errorf("could not parse config file %v %v", p, err)
+	}
+
+	l.files[p] = ly
+
+	return ly, nil
+}
+
+// merge returns the config of the nearest of layers. A top level
+// min_coverage_percentage of any but the farthest layer becomes an implicit
+// entry for the packages below its file, which loses to the entries of its
+// own file but wins over those of farther files.
+func merge(layers []*layer, importPath func(dir string) string) (ConfigFile, error) {
+	cfg, err := mergeSettings(layers)
+	if err != nil {
+		return ConfigFile{}, err
+	}
+
+	for i := range layers {
+		if err := cfg.addLayer(layers, i, importPath); err != nil {
+			return ConfigFile{}, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// addLayer adds the rules and waivers of layers[i] after those of the nearer
+// layers
+func (c *ConfigFile) addLayer(layers []*layer, i int, importPath func(dir string) string) error {
+	ly := layers[i]
+	c.sources = append(c.sources, ly.path)
+
+	for j, p := range ly.cfg.Packages {
+		c.Packages = append(c.Packages, p)
+		c.matchers = append(c.matchers, ly.cfg.matchers[j])
+		c.packageOrigins = append(c.packageOrigins, origin{path: ly.path, layer: i, index: j})
+	}
+
+	if _, ok := ly.raw[minCoverageKey]; ok && i < len(layers)-1 {
+		if err := c.addImplicitPackage(layers[i:], i, importPath); err != nil {
+			return err
+		}
+	}
+
+	for j, f := range ly.cfg.Files {
+		c.Files = append(c.Files, f)
+		c.fileMatchers = append(c.fileMatchers, ly.cfg.fileMatchers[j])
+		c.fileOrigins = append(c.fileOrigins, origin{path: ly.path, layer: i, index: j})
+	}
+
+	for j, w := range ly.cfg.Waivers {
+		c.Waivers = append(c.Waivers, w)
+		c.waiverOrigins = append(c.waiverOrigins, origin{path: ly.path, layer: i, index: j})
+	}
+
+	return nil
+}
+
+// mergeSettings returns a config with the top level settings of layers, those
+// of nearer layers win
+func mergeSettings(layers []*layer) (ConfigFile, error) {
+	settings := make(map[string]interface{})
+
+	for i := len(layers) - 1; i >= 0; i-- {
+		for k, v := range layers[i].raw {
+			if k == "packages" || k == "files" || k == "waivers" {
+				continue
+			}
+
+			settings[k] = v
+		}
+	}
+
+	content, err := yaml.Marshal(settings)
+	if err != nil {
+		return ConfigFile{}, err
+	}
+
+	return ParseConfigFile(content)
+}
+
+// addImplicitPackage adds the entry for the top level settings of layers[0],
+// merged with those of the farther layers, as a rule of layer depth
+func (c *ConfigFile) addImplicitPackage(layers []*layer, depth int, importPath func(dir string) string) error {
+	settings, err := mergeSettings(layers)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(layers[0].path)
+	if importPath != nil {
+		dir = importPath(dir)
+	}
+
+	name := path.Join(filepath.ToSlash(dir), "...")
+
+	c.Packages = append(c.Packages, settings.GlobalPackage(name))
+	// a merged config only applies to packages below the file of each layer
+	c.matchers = append(c.matchers, packageMatcher{kind: kindImplicit, re: regexp.MustCompile(""), name: name})
+	c.packageOrigins = append(c.packageOrigins, origin{path: layers[0].path, layer: depth, index: -1})
+
+	return nil
+}
+
+// Sources returns the config files c was merged from, nearest first
+func (c ConfigFile) Sources() []string {
+	return c.sources
+}
+
+// PackageOwner returns the config file holding the rule which applies to pkg
+// and the index of that rule in the packages of the file, -1 for its top level
+// min_coverage_percentage. A package no rule matches belongs to the farthest
+// file. The path is empty if c was not loaded by a Loader.
+func (c ConfigFile) PackageOwner(pkg string) (string, int) {
+	_, i, _ := c.MatchPackage(pkg)
+	if i != -1 && i < len(c.packageOrigins) {
+		return c.packageOrigins[i].path, c.packageOrigins[i].index
+	}
+
+	if i == -1 && len(c.sources) > 0 {
+		return c.sources[len(c.sources)-1], -1
+	}
+
+	return "", i
+}
+
+// PackageRule describes entry i of Packages as packages[index] "name", followed
+// by the config file it came from if c was loaded by a Loader
+func (c ConfigFile) PackageRule(i int) string {
+	return describeRule("packages", i, c.Packages[i].Name, c.packageOrigins)
+}
+
+// FileRule describes entry i of Files like PackageRule
+func (c ConfigFile) FileRule(i int) string {
+	return describeRule("files", i, c.Files[i].Name, c.fileOrigins)
+}
+
+func describeRule(key string, i int, name string, origins []origin) string {
+	if i >= len(origins) {
+		return fmt.Sprintf("%v[%v] %q", key, i, name)
+	}
+
+	if origins[i].index == -1 {
+		return fmt.Sprintf("%v in %v", minCoverageKey, origins[i].path)
+	}
+
+	return fmt.Sprintf("%v[%v] %q in %v", key, origins[i].index, name, origins[i].path)
+}
+
+// originLayers returns the layer of each of origins
+func originLayers(origins []origin) []int {
+	if len(origins) == 0 {
+		return nil
+	}
+
+	l := make([]int, len(origins))
+	for i, o := range origins {
+		l[i] = o.layer
+	}
+
+	return l
+}
@@ -0,0 +1,225 @@
+// Copyright 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Loader(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	root, err := ioutil.TempDir("", "gocheckcov")
+	g.Expect(err).To(BeNil())
+
+	defer os.RemoveAll(root)
+
+	writeConfig := func(dir, content string) string {
+		g.Expect(os.MkdirAll(dir, 0755)).To(BeNil())
+
+		p := filepath.Join(dir, DefaultConfigPath)
+		g.Expect(ioutil.WriteFile(p, []byte(content), 0644)).To(BeNil())
+
+		return p
+	}
+
+	rootConfig := writeConfig(root, `
+min_coverage_percentage: 50
+max_crap_score: 30
+packages:
+- name: example.com/svc/billing
+  min_coverage_percentage: 90
+files:
+- name: "**/table.go"
+  min_coverage_percentage: 0
+waivers:
+- package: example.com/svc/...
+  reason: flaky test environment
+  owner: alice
+  expires: 2026-12-31
+`)
+	billingConfig := writeConfig(filepath.Join(root, "billing"), `
+min_coverage_percentage: 80
+packages:
+- name: example.com/svc/...
+  min_coverage_percentage: 75
+waivers:
+- package: example.com/svc/billing
+  reason: payment provider sandbox is down
+  owner: bob
+  expires: 2026-11-30
+`)
+	other := root + "-other"
+	writeConfig(other, "min_coverage_percentage: 1")
+
+	defer os.RemoveAll(other)
+
+	nested := filepath.Join(root, "billing", "invoice")
+	g.Expect(os.MkdirAll(nested, 0755)).To(BeNil())
+
+	l := NewLoader(root)
+	l.Fallback = ""
+
+	paths, err := l.Paths(nested)
+	g.Expect(err).To(BeNil())
+	g.Expect(paths).To(Equal([]string{billingConfig, rootConfig}))
+
+	cfg, err := l.Load(nested)
+	g.Expect(err).To(BeNil())
+	g.Expect(cfg.Sources()).To(Equal(paths))
+	g.Expect(cfg.MinCoveragePercentage).To(Equal(float64(80)))
+	g.Expect(cfg.MaxCRAPScore).To(Equal(float64(30)))
+
+	// the glob of the nearer file wins over the exact name of the farther one
+	p, i, ok := cfg.MatchPackage("example.com/svc/billing")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(p.MinCoveragePercentage).To(Equal(float64(75)))
+	g.Expect(cfg.PackageRule(i)).To(Equal(`packages[0] "example.com/svc/..." in ` + billingConfig))
+
+	_, i, ok = cfg.MatchFile("example.com/svc/billing/table.go")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(cfg.FileRule(i)).To(Equal(`files[0] "**/table.go" in ` + rootConfig))
+
+	// waivers of the nearer file come first
+	w, i, ok := cfg.MatchWaiver("example.com/svc/billing", "", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC))
+	g.Expect(ok).To(BeTrue())
+	g.Expect(w.Owner).To(Equal("bob"))
+	g.Expect(cfg.WaiverRule(i)).To(Equal(`waivers[0] "example.com/svc/billing" in ` + billingConfig))
+	g.Expect(cfg.WaiverRule(1)).To(Equal(`waivers[0] "example.com/svc/..." in ` + rootConfig))
+
+	cfg, err = l.Load(root)
+	g.Expect(err).To(BeNil())
+	g.Expect(cfg.Sources()).To(Equal([]string{rootConfig}))
+
+	_, i, _ = cfg.MatchPackage("example.com/svc/billing")
+	g.Expect(cfg.Packages[i].MinCoveragePercentage).To(Equal(float64(90)))
+
+	// directories outside of the root only get the fallback
+	cfg, err = l.Load(other)
+	g.Expect(err).To(BeNil())
+	g.Expect(cfg.Sources()).To(BeEmpty())
+
+	l.Fallback = rootConfig
+
+	paths, err = l.Paths(other)
+	g.Expect(err).To(BeNil())
+	g.Expect(paths).To(Equal([]string{rootConfig}))
+
+	writeConfig(filepath.Join(root, "bad"), "packages:\n- name: ^(svc\n")
+
+	_, err = l.Load(filepath.Join(root, "bad"))
+	g.Expect(err).ToNot(BeNil())
+}
+
+func Test_Loader_topLevelMinimum(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	root, err := ioutil.TempDir("", "gocheckcov")
+	g.Expect(err).To(BeNil())
+
+	defer os.RemoveAll(root)
+
+	writeConfig := func(dir, content string) string {
+		g.Expect(os.MkdirAll(dir, 0755)).To(BeNil())
+
+		p := filepath.Join(dir, DefaultConfigPath)
+		g.Expect(ioutil.WriteFile(p, []byte(content), 0644)).To(BeNil())
+
+		return p
+	}
+
+	rootConfig := writeConfig(root, `
+min_coverage_percentage: 80
+packages:
+- name: example.com/svc/pkg/...
+  min_coverage_percentage: 50
+`)
+	aConfig := writeConfig(filepath.Join(root, "pkg", "a"), `
+min_coverage_percentage: 5
+packages:
+- name: ^example.com/svc/pkg/a/internal$
+  min_coverage_percentage: 70
+`)
+
+	l := NewLoader(root)
+	l.Fallback = ""
+	l.ImportPath = func(dir string) string {
+		rel, err := filepath.Rel(root, dir)
+		g.Expect(err).To(BeNil())
+
+		return "example.com/svc/" + filepath.ToSlash(rel)
+	}
+
+	// the top level minimum of the nearer file wins over the glob of the
+	// farther one
+	cfg, err := l.Load(filepath.Join(root, "pkg", "a"))
+	g.Expect(err).To(BeNil())
+
+	p, i, ok := cfg.MatchPackage("example.com/svc/pkg/a")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(p.MinCoveragePercentage).To(Equal(float64(5)))
+	g.Expect(cfg.Packages[i].Name).To(Equal("example.com/svc/pkg/a/..."))
+	g.Expect(cfg.PackageRule(i)).To(Equal("min_coverage_percentage in " + aConfig))
+
+	owner, entry := cfg.PackageOwner("example.com/svc/pkg/a")
+	g.Expect(owner).To(Equal(aConfig))
+	g.Expect(entry).To(Equal(-1))
+
+	// so do the packages below it
+	cfg, err = l.Load(filepath.Join(root, "pkg", "a", "b"))
+	g.Expect(err).To(BeNil())
+
+	p, _, ok = cfg.MatchPackage("example.com/svc/pkg/a/b")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(p.MinCoveragePercentage).To(Equal(float64(5)))
+
+	// entries of the same file win over its top level minimum
+	cfg, err = l.Load(filepath.Join(root, "pkg", "a", "internal"))
+	g.Expect(err).To(BeNil())
+
+	p, _, ok = cfg.MatchPackage("example.com/svc/pkg/a/internal")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(p.MinCoveragePercentage).To(Equal(float64(70)))
+
+	owner, entry = cfg.PackageOwner("example.com/svc/pkg/a/internal")
+	g.Expect(owner).To(Equal(aConfig))
+	g.Expect(entry).To(Equal(0))
+
+	// packages without a nearer file still get the glob
+	cfg, err = l.Load(filepath.Join(root, "pkg", "c"))
+	g.Expect(err).To(BeNil())
+
+	p, _, ok = cfg.MatchPackage("example.com/svc/pkg/c")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(p.MinCoveragePercentage).To(Equal(float64(50)))
+
+	owner, entry = cfg.PackageOwner("example.com/svc/pkg/c")
+	g.Expect(owner).To(Equal(rootConfig))
+	g.Expect(entry).To(Equal(0))
+
+	// packages no entry matches belong to the farthest file
+	cfg, err = l.Load(filepath.Join(root, "cmd"))
+	g.Expect(err).To(BeNil())
+
+	owner, entry = cfg.PackageOwner("example.com/svc/cmd")
+	g.Expect(owner).To(Equal(rootConfig))
+	g.Expect(entry).To(Equal(-1))
+}
@@ -0,0 +1,203 @@
+// Copyright © 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// PackageCoverage is the current coverage of a package for Init
+type PackageCoverage struct {
+	Name            string
+	CoveragePercent float64
+	StatementCount  int64
+}
+
+// Init returns a config with an entry at the current coverage of each of
+// packages, sorted by name, merged into existing. Entries of existing are kept
+// as they are and packages they already match are left out. Packages with
+// fewer than smallPackageStatements statements don't get an entry, instead
+// the global min_coverage_percentage is set to the lowest coverage of them if
+// existing doesn't set it. If existing sets it, small packages below it get an
+// entry so they pass. It also returns the names of the added entries.
+func Init(existing []byte, packages []PackageCoverage, smallPackageStatements int64) ([]byte, []string, error) {
+	cfg, err := ParseConfigFile(existing)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	raw := make(map[string]interface{})
+	if err := yaml.Unmarshal(existing, &raw); err != nil {
+		return nil, nil, err
+	}
+
+	_, hasGlobal := raw[minCoverageKey]
+
+	sort.Slice(packages, func(i, j int) bool { return packages[i].Name < packages[j].Name })
+
+	entries, global := newEntries(cfg, hasGlobal, packages, smallPackageStatements)
+
+	added := make([]string, 0, len(entries))
+	for _, e := range entries {
+		added = append(added, e.Name)
+	}
+
+	if len(strings.TrimSpace(string(existing))) == 0 {
+		fresh := ConfigFile{Packages: entries}
+		if !math.IsInf(global, 1) {
+			fresh.MinCoveragePercentage = global
+		}
+
+		out, err := yaml.Marshal(fresh)
+
+		return out, added, err
+	}
+
+	if hasGlobal {
+		global = math.Inf(1)
+	}
+
+	out, err := mergeEntries(existing, len(cfg.Packages), entries, global)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return out, added, nil
+}
+
+// newEntries returns an entry for each of packages cfg doesn't match yet, and
+// the lowest coverage of the small packages left to the global minimum, which
+// is +Inf if there are none
+func newEntries(
+	cfg ConfigFile,
+	hasGlobal bool,
+	packages []PackageCoverage,
+	smallPackageStatements int64,
+) ([]ConfigPackage, float64) {
+	entries := make([]ConfigPackage, 0)
+	global := math.Inf(1)
+
+	for _, p := range packages {
+		if _, _, ok := cfg.MatchPackage(p.Name); ok {
+			continue
+		}
+
+		small := p.StatementCount < smallPackageStatements
+		if small && (!hasGlobal || p.CoveragePercent >= cfg.MinCoveragePercentage) {
+			global = math.Min(global, p.CoveragePercent)
+			continue
+		}
+
+		entries = append(entries, ConfigPackage{Name: p.Name, MinCoveragePercentage: p.CoveragePercent})
+	}
+
+	return entries, global
+}
+
+// mergeEntries adds entries to the packages of existing, which has count of
+// them, and sets the global minimum to global unless it is +Inf. The comments
+// and ordering of existing are kept.
+func mergeEntries(existing []byte, count int, entries []ConfigPackage, global float64) ([]byte, error) {
+	lines := strings.Split(strings.TrimSuffix(string(existing), "\n"), "\n")
+
+	if len(entries) > 0 {
+		var err error
+		if lines, err = appendPackages(lines, entries); err != nil {
+			return nil, err
+		}
+	}
+
+	if !math.IsInf(global, 1) {
+		lines = setKey(lines, 0, len(lines), 0, strconv.FormatFloat(global, 'f', -1, 64))
+	}
+
+	out := []byte(strings.Join(lines, "\n") + "\n")
+
+	updated, err := ParseConfigFile(out)
+	if err != nil {
+		return nil, fmt.Errorf("could not merge config %v", err)
+	}
+
+	if len(updated.Packages) != count+len(entries) {
+		return nil, fmt.Errorf("could not merge config, only block style package lists can be merged")
+	}
+
+	return out, nil
+}
+
+// appendPackages adds entries to the end of the packages list of lines,
+// adding the list if there is none
+func appendPackages(lines []string, entries []ConfigPackage) ([]string, error) {
+	content, err := yaml.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	items := packageItems(lines)
+	at := len(lines)
+	indent := ""
+
+	switch {
+	case len(items) > 0:
+		last := items[len(items)-1]
+		at = last.end
+
+		// leave blank lines and comments after the last entry where they are
+		for at > last.start+1 && isBlankOrComment(lines[at-1]) {
+			at--
+		}
+
+		indent = strings.Repeat(" ", last.indent-2)
+	default:
+		found := false
+
+		for i, line := range lines {
+			if m := topLevelKeyRe.FindStringSubmatch(line); m != nil && m[1] == "packages" {
+				lines[i] = "packages:"
+				at = i + 1
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			lines = append(lines, "packages:")
+			at = len(lines)
+		}
+	}
+
+	added := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	for i := range added {
+		added[i] = indent + added[i]
+	}
+
+	out := make([]string, 0, len(lines)+len(added))
+	out = append(out, lines[:at]...)
+	out = append(out, added...)
+
+	return append(out, lines[at:]...), nil
+}
+
+func isBlankOrComment(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed == "" || strings.HasPrefix(trimmed, "#")
+}
@@ -0,0 +1,162 @@
+// Copyright 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Init(t *testing.T) {
+	packages := []PackageCoverage{
+		{Name: "example.com/svc/tax", CoveragePercent: 62.5, StatementCount: 80},
+		{Name: "example.com/svc/billing", CoveragePercent: 70, StatementCount: 200},
+		{Name: "example.com/svc/version", CoveragePercent: 50, StatementCount: 2},
+		{Name: "example.com/svc/internal/db", CoveragePercent: 10, StatementCount: 300},
+	}
+
+	type testcase struct {
+		existing      string
+		small         int64
+		expectAdded   []string
+		expectContent string
+	}
+
+	testCases := map[string]testcase{
+		"new config is sorted": {
+			expectAdded: []string{
+				"example.com/svc/billing",
+				"example.com/svc/internal/db",
+				"example.com/svc/tax",
+				"example.com/svc/version",
+			},
+			expectContent: `min_coverage_percentage: 0
+packages:
+- name: example.com/svc/billing
+  min_coverage_percentage: 70
+- name: example.com/svc/internal/db
+  min_coverage_percentage: 10
+- name: example.com/svc/tax
+  min_coverage_percentage: 62.5
+- name: example.com/svc/version
+  min_coverage_percentage: 50
+`,
+		},
+		"small packages fall back to the global minimum": {
+			small:       100,
+			expectAdded: []string{"example.com/svc/billing", "example.com/svc/internal/db"},
+			expectContent: `min_coverage_percentage: 50
+packages:
+- name: example.com/svc/billing
+  min_coverage_percentage: 70
+- name: example.com/svc/internal/db
+  min_coverage_percentage: 10
+`,
+		},
+		"hand edited entries are kept": {
+			existing: `# owned by the payments team
+packages:
+- name: example.com/svc/billing # raised by hand
+  min_coverage_percentage: 90
+
+# everything internal
+- name: example.com/svc/internal/...
+  min_coverage_percentage: 0
+files:
+- name: "**/table.go"
+  min_coverage_percentage: 0
+`,
+			small:       10,
+			expectAdded: []string{"example.com/svc/tax"},
+			expectContent: `# owned by the payments team
+min_coverage_percentage: 50
+packages:
+- name: example.com/svc/billing # raised by hand
+  min_coverage_percentage: 90
+
+# everything internal
+- name: example.com/svc/internal/...
+  min_coverage_percentage: 0
+- name: example.com/svc/tax
+  min_coverage_percentage: 62.5
+files:
+- name: "**/table.go"
+  min_coverage_percentage: 0
+`,
+		},
+		"existing global minimum is kept": {
+			existing: "min_coverage_percentage: 30\n",
+			small:    100,
+			expectAdded: []string{
+				"example.com/svc/billing",
+				"example.com/svc/internal/db",
+			},
+			expectContent: `min_coverage_percentage: 30
+packages:
+- name: example.com/svc/billing
+  min_coverage_percentage: 70
+- name: example.com/svc/internal/db
+  min_coverage_percentage: 10
+`,
+		},
+		"small packages below an existing global minimum get an entry": {
+			existing: "min_coverage_percentage: 60\n",
+			small:    100,
+			expectAdded: []string{
+				"example.com/svc/billing",
+				"example.com/svc/internal/db",
+				"example.com/svc/version",
+			},
+			expectContent: `min_coverage_percentage: 60
+packages:
+- name: example.com/svc/billing
+  min_coverage_percentage: 70
+- name: example.com/svc/internal/db
+  min_coverage_percentage: 10
+- name: example.com/svc/version
+  min_coverage_percentage: 50
+`,
+		},
+		"global minimum after a document marker": {
+			existing: `---
+packages:
+- name: example.com/svc/billing
+  min_coverage_percentage: 90
+`,
+			small:       100,
+			expectAdded: []string{"example.com/svc/internal/db"},
+			expectContent: `---
+min_coverage_percentage: 50
+packages:
+- name: example.com/svc/billing
+  min_coverage_percentage: 90
+- name: example.com/svc/internal/db
+  min_coverage_percentage: 10
+`,
+		},
+	}
+
+	for desc := range testCases {
+		tc := testCases[desc]
+		t.Run(desc, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			out, added, err := Init([]byte(tc.existing), append([]PackageCoverage{}, packages...), tc.small)
+			g.Expect(err).To(BeNil())
+			g.Expect(added).To(Equal(tc.expectAdded))
+			g.Expect(string(out)).To(Equal(tc.expectContent))
+		})
+	}
+}
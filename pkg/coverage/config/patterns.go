@@ -0,0 +1,216 @@
+// Copyright © 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// kindImplicit is the entry a merged config gets for the top level minimum
+	// of a config file, it is less specific than any pattern
+	kindImplicit = iota
+	kindRegex
+	kindGlob
+	kindExact
+)
+
+// packageMatcher matches package names against the name of a ConfigPackage.
+// Names starting with ^ are regular expressions. Names containing ..., * or ?
+// are globs, where ... and ** match any number of path elements and * and ?
+// match within one element. Any other name matches only itself.
+type packageMatcher struct {
+	kind    int
+	literal int
+	re      *regexp.Regexp
+	name    string
+}
+
+func newPackageMatcher(name string) (packageMatcher, error) {
+	if strings.HasPrefix(name, "^") {
+		re, err := regexp.Compile(name)
+		if err != nil {
+			return packageMatcher{}, fmt.Errorf("invalid package pattern %v %v", name, err)
+		}
+
+		return packageMatcher{kind: kindRegex, re: re, name: name}, nil
+	}
+
+	if !strings.ContainsAny(name, "*?") && !strings.Contains(name, "...") {
+		return packageMatcher{kind: kindExact, literal: len(name), name: name}, nil
+	}
+
+	expr, literal := globToRegexp(name)
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return packageMatcher{}, fmt.Errorf("invalid package pattern %v %v", name, err)
+	}
+
+	return packageMatcher{kind: kindGlob, literal: literal, re: re, name: name}, nil
+}
+
+// newFunctionMatcher returns a matcher for a function name. It is a package
+// pattern, except that the * of a leading pointer receiver like (*Client) is
+// part of the name rather than a glob.
+func newFunctionMatcher(name string) (packageMatcher, error) {
+	const pointer = "(*"
+
+	if !strings.HasPrefix(name, pointer) {
+		return newPackageMatcher(name)
+	}
+
+	m, err := newPackageMatcher(name[len(pointer):])
+	if err != nil {
+		return packageMatcher{}, fmt.Errorf("invalid function pattern %v %v", name, err)
+	}
+
+	m.name = name
+	m.literal += len(pointer)
+
+	if m.kind == kindGlob {
+		m.re = regexp.MustCompile("^" + regexp.QuoteMeta(pointer) + strings.TrimPrefix(m.re.String(), "^"))
+	}
+
+	return m, nil
+}
+
+func (m packageMatcher) match(pkg string) bool {
+	if m.kind == kindExact {
+		return pkg == m.name
+	}
+
+	return m.re.MatchString(pkg)
+}
+
+// moreSpecific reports whether m is more specific than other. Exact names beat
+// globs which beat regular expressions, and globs with more literal characters
+// beat those with fewer.
+func (m packageMatcher) moreSpecific(other packageMatcher) bool {
+	if m.kind != other.kind {
+		return m.kind > other.kind
+	}
+
+	return m.literal > other.literal
+}
+
+// compileMatchers returns a matcher for each of names. Invalid names are
+// reported by ParseConfigFile, here they never match.
+func compileMatchers(names []string) []packageMatcher {
+	matchers := make([]packageMatcher, len(names))
+
+	for i, name := range names {
+		m, err := newPackageMatcher(name)
+		if err != nil {
+			m = packageMatcher{kind: kindExact, name: name}
+		}
+
+		matchers[i] = m
+	}
+
+	return matchers
+}
+
+// compileFunctionMatchers is compileMatchers for function names
+func compileFunctionMatchers(names []string) []packageMatcher {
+	matchers := make([]packageMatcher, len(names))
+
+	for i, name := range names {
+		m, err := newFunctionMatcher(name)
+		if err != nil {
+			m = packageMatcher{kind: kindExact, name: name}
+		}
+
+		matchers[i] = m
+	}
+
+	return matchers
+}
+
+// bestMatch returns the index of the most specific of matchers which matches
+// name, the first one wins a tie. If layers is set a matcher in a lower layer
+// wins over any in a higher one. It returns -1 if none match.
+func bestMatch(matchers []packageMatcher, layers []int, name string) int {
+	best := -1
+
+	layer := func(i int) int {
+		if i < len(layers) {
+			return layers[i]
+		}
+
+		return 0
+	}
+
+	for i, m := range matchers {
+		if !m.match(name) {
+			continue
+		}
+
+		switch {
+		case best == -1 || layer(i) < layer(best):
+			best = i
+		case layer(i) == layer(best) && m.moreSpecific(matchers[best]):
+			best = i
+		}
+	}
+
+	return best
+}
+
+// globToRegexp returns an anchored regular expression for pattern and the
+// number of literal characters in it
+func globToRegexp(pattern string) (string, int) {
+	var b strings.Builder
+
+	literal := 0
+
+	b.WriteString("^")
+
+	for i := 0; i < len(pattern); {
+		rest := pattern[i:]
+
+		switch {
+		// like the go tool, a trailing /... also matches the directory itself
+		case rest == "/..." || rest == "/**":
+			b.WriteString("(/.*)?")
+			i += len(rest)
+		case strings.HasPrefix(rest, "**/"):
+			b.WriteString("(.*/)?")
+			i += 3
+		case strings.HasPrefix(rest, "..."):
+			b.WriteString(".*")
+			i += 3
+		case strings.HasPrefix(rest, "**"):
+			b.WriteString(".*")
+			i += 2
+		case rest[0] == '*':
+			b.WriteString("[^/]*")
+			i++
+		case rest[0] == '?':
+			b.WriteString("[^/]")
+			i++
+		default:
+			b.WriteString(regexp.QuoteMeta(rest[:1]))
+			literal++
+			i++
+		}
+	}
+
+	b.WriteString("$")
+
+	return b.String(), literal
+}
@@ -0,0 +1,315 @@
+// Copyright © 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const minCoverageKey = "min_coverage_percentage"
+
+// Change is a min_coverage_percentage raised by Ratchet. Entry is -1 for the
+// global minimum.
+type Change struct {
+	Entry int
+	Name  string
+	Old   float64
+	New   float64
+}
+
+func (c Change) String() string {
+	if c.Entry == -1 {
+		return fmt.Sprintf("%v %v -> %v", minCoverageKey, c.Old, c.New)
+	}
+
+	return fmt.Sprintf("packages[%v] %q %v %v -> %v", c.Entry, c.Name, minCoverageKey, c.Old, c.New)
+}
+
+// Ratchet raises the min_coverage_percentage of each package entry in content
+// to the lowest coverage of the packages it applies to, less margin. The
+// global minimum is raised the same way for the packages no entry matches.
+// Thresholds are never lowered. The returned content keeps the comments and
+// ordering of content.
+func Ratchet(content []byte, coverage map[string]float64, margin float64) ([]byte, []Change, error) {
+	cfg, err := ParseConfigFile(content)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	targets := make(map[int]float64)
+
+	for pkg, cov := range coverage {
+		_, i, _ := cfg.MatchPackage(pkg)
+		AddTarget(targets, i, RatchetTarget(cov, margin))
+	}
+
+	return RaiseEntries(content, targets)
+}
+
+// RatchetTarget returns the threshold for a package with coverage cov, less
+// margin and rounded down to two decimals
+func RatchetTarget(cov, margin float64) float64 {
+	// coverage already has two decimals, the epsilon keeps floor from turning
+	// 33.3 into 33.29
+	return math.Floor(math.Max(cov-margin, 0)*100+1e-9) / 100
+}
+
+// AddTarget records target for entry in targets unless a lower one is already
+// recorded
+func AddTarget(targets map[int]float64, entry int, target float64) {
+	if t, ok := targets[entry]; !ok || target < t {
+		targets[entry] = target
+	}
+}
+
+// RaiseEntries raises the min_coverage_percentage of the entries of content to
+// their targets, entry -1 being the global minimum. Like Ratchet it never
+// lowers a threshold and keeps the comments and ordering of content.
+func RaiseEntries(content []byte, targets map[int]float64) ([]byte, []Change, error) {
+	cfg, err := ParseConfigFile(content)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	changes, err := raisedEntries(cfg, targets)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(changes) == 0 {
+		return content, changes, nil
+	}
+
+	out, err := setMinCoverage(content, changes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// make sure the edit did what was meant before anyone writes it out
+	if err := verifyChanges(out, changes); err != nil {
+		return nil, nil, err
+	}
+
+	return out, changes, nil
+}
+
+// raisedEntries returns a Change for each entry of cfg whose target is above
+// its threshold, in the order of the entries
+func raisedEntries(cfg ConfigFile, targets map[int]float64) ([]Change, error) {
+	entries := make([]int, 0, len(targets))
+	for i := range targets {
+		entries = append(entries, i)
+	}
+
+	sort.Ints(entries)
+
+	changes := make([]Change, 0)
+
+	for _, i := range entries {
+		if i >= len(cfg.Packages) {
+			return nil, fmt.Errorf("config has no packages[%v]", i)
+		}
+
+		c := Change{Entry: i, Old: cfg.MinCoveragePercentage, New: targets[i]}
+		if i != -1 {
+			c.Name = cfg.Packages[i].Name
+			c.Old = cfg.Packages[i].MinCoveragePercentage
+		}
+
+		if c.New > c.Old {
+			changes = append(changes, c)
+		}
+	}
+
+	return changes, nil
+}
+
+// verifyChanges checks that the thresholds of out are those set by changes
+func verifyChanges(out []byte, changes []Change) error {
+	updated, err := ParseConfigFile(out)
+	if err != nil {
+		return fmt.Errorf("could not update config %v", err)
+	}
+
+	for _, c := range changes {
+		got := updated.MinCoveragePercentage
+		if c.Entry != -1 {
+			if c.Entry >= len(updated.Packages) {
+				return fmt.Errorf("could not update %v in config, packages[%v] is missing", c, c.Entry)
+			}
+
+			got = updated.Packages[c.Entry].MinCoveragePercentage
+		}
+
+		if got != c.New {
+			return fmt.Errorf("could not update %v in config", c)
+		}
+	}
+
+	return nil
+}
+
+var (
+	topLevelKeyRe    = regexp.MustCompile(`^([A-Za-z_]+):`)
+	documentMarkerRe = regexp.MustCompile(`^---(\s+#.*)?$`)
+	minCoverageRe    = regexp.MustCompile(`^(\s*(?:- )?` + minCoverageKey + `:\s*)([^\s#]*)(.*)$`)
+)
+
+// setMinCoverage edits the lines of content which hold the thresholds of
+// changes, adding them where they are missing
+func setMinCoverage(content []byte, changes []Change) ([]byte, error) {
+	lines := strings.Split(string(content), "\n")
+	items := packageItems(lines)
+
+	// edit from the bottom up so inserted lines don't move the ones still
+	// to be edited
+	for j := len(changes) - 1; j >= 0; j-- {
+		c := changes[j]
+		value := strconv.FormatFloat(c.New, 'f', -1, 64)
+
+		start, end, indent := 0, len(lines), 0
+
+		if c.Entry != -1 {
+			if c.Entry >= len(items) {
+				return nil, fmt.Errorf("could not find packages[%v] in config, only block style lists can be updated", c.Entry)
+			}
+
+			start, end, indent = items[c.Entry].start, items[c.Entry].end, items[c.Entry].indent
+		}
+
+		lines = setKey(lines, start, end, indent, value)
+	}
+
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// setKey sets min_coverage_percentage to value on the line of lines[start:end]
+// where it is a key at indent, or inserts it after the first line
+func setKey(lines []string, start, end, indent int, value string) []string {
+	for i := start; i < end; i++ {
+		m := minCoverageRe.FindStringSubmatch(lines[i])
+		if m == nil || len(m[1])-len(strings.TrimLeft(m[1], " -")) != indent {
+			continue
+		}
+
+		lines[i] = m[1] + value + m[3]
+
+		return lines
+	}
+
+	line := strings.Repeat(" ", indent) + minCoverageKey + ": " + value
+	at := start
+
+	if indent != 0 {
+		at = start + 1
+	} else {
+		at = documentStart(lines)
+	}
+
+	out := make([]string, 0, len(lines)+1)
+	out = append(out, lines[:at]...)
+	out = append(out, line)
+
+	return append(out, lines[at:]...)
+}
+
+// documentStart returns the index of the first line after the leading
+// comments and document start marker of lines, so keys inserted there stay
+// in the first YAML document
+func documentStart(lines []string) int {
+	start := 0
+	marker := false
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			continue
+		case strings.HasPrefix(trimmed, "#"):
+		case documentMarkerRe.MatchString(trimmed) && !marker:
+			marker = true
+		default:
+			return start
+		}
+
+		start = i + 1
+	}
+
+	return start
+}
+
+type item struct {
+	start, end, indent int
+}
+
+// packageItems returns the line range of each entry of packages and the
+// indent of its keys. Only block style lists are supported.
+func packageItems(lines []string) []item {
+	items := make([]item, 0)
+	inPackages := false
+	dashIndent := -1
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if m := topLevelKeyRe.FindStringSubmatch(line); m != nil {
+			if inPackages {
+				endItem(items, i)
+			}
+
+			inPackages = m[1] == "packages"
+
+			continue
+		}
+
+		if inPackages && itemStart(line, &dashIndent) {
+			endItem(items, i)
+			items = append(items, item{start: i, end: len(lines), indent: dashIndent + 2})
+		}
+	}
+
+	return items
+}
+
+// endItem ends the last of items before line i
+func endItem(items []item, i int) {
+	if len(items) > 0 {
+		items[len(items)-1].end = i
+	}
+}
+
+// itemStart reports whether line starts an entry of a block style list whose
+// dashes are indented by dashIndent, which the first entry sets
+func itemStart(line string, dashIndent *int) bool {
+	if !strings.HasPrefix(strings.TrimSpace(line), "- ") {
+		return false
+	}
+
+	indent := len(line) - len(strings.TrimLeft(line, " "))
+	if *dashIndent == -1 {
+		*dashIndent = indent
+	}
+
+	return indent == *dashIndent
+}
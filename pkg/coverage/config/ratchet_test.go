@@ -0,0 +1,172 @@
+// Copyright 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Ratchet(t *testing.T) {
+	content := `# thresholds for the svc module
+min_coverage_percentage: 40 # everything else
+packages:
+# billing is owned by the payments team
+- name: example.com/svc/billing
+  min_coverage_percentage: 70
+  functions:
+  - name: Charge
+    min_coverage_percentage: 90
+- name: example.com/svc/internal/...
+  min_coverage_percentage: 80
+- name: example.com/svc/api
+files:
+- name: "**/table.go"
+  min_coverage_percentage: 0
+`
+
+	type testcase struct {
+		coverage      map[string]float64
+		margin        float64
+		expectChanges []Change
+		expectContent string
+	}
+
+	testCases := map[string]testcase{
+		"raises thresholds and keeps comments": {
+			coverage: map[string]float64{
+				"example.com/svc/billing":       75.55,
+				"example.com/svc/internal/db":   90,
+				"example.com/svc/internal/auth": 85.5,
+				"example.com/svc/api":           60,
+				"example.com/svc/cmd":           45,
+			},
+			margin: 0.5,
+			expectChanges: []Change{
+				{Entry: -1, Old: 40, New: 44.5},
+				{Entry: 0, Name: "example.com/svc/billing", Old: 70, New: 75.05},
+				{Entry: 1, Name: "example.com/svc/internal/...", Old: 80, New: 85},
+				{Entry: 2, Name: "example.com/svc/api", Old: 0, New: 59.5},
+			},
+			expectContent: `# thresholds for the svc module
+min_coverage_percentage: 44.5 # everything else
+packages:
+# billing is owned by the payments team
+- name: example.com/svc/billing
+  min_coverage_percentage: 75.05
+  functions:
+  - name: Charge
+    min_coverage_percentage: 90
+- name: example.com/svc/internal/...
+  min_coverage_percentage: 85
+- name: example.com/svc/api
+  min_coverage_percentage: 59.5
+files:
+- name: "**/table.go"
+  min_coverage_percentage: 0
+`,
+		},
+		"never lowers a threshold": {
+			coverage: map[string]float64{
+				"example.com/svc/billing":     50,
+				"example.com/svc/internal/db": 80.2,
+			},
+			margin:        1,
+			expectChanges: []Change{},
+			expectContent: content,
+		},
+	}
+
+	for desc := range testCases {
+		tc := testCases[desc]
+		t.Run(desc, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			out, changes, err := Ratchet([]byte(content), tc.coverage, tc.margin)
+			g.Expect(err).To(BeNil())
+			g.Expect(changes).To(Equal(tc.expectChanges))
+			g.Expect(string(out)).To(Equal(tc.expectContent))
+		})
+	}
+}
+
+func Test_Ratchet_missing_global(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	out, changes, err := Ratchet([]byte("# generated\npackages: []\n"), map[string]float64{"foo": 33.3}, 0)
+	g.Expect(err).To(BeNil())
+	g.Expect(changes).To(HaveLen(1))
+	g.Expect(string(out)).To(Equal("# generated\nmin_coverage_percentage: 33.3\npackages: []\n"))
+}
+
+func Test_Ratchet_document_marker(t *testing.T) {
+	type testcase struct {
+		description   string
+		content       string
+		expectContent string
+	}
+
+	testCases := []testcase{
+		{
+			description: "marker",
+			content:     "---\npackages:\n- name: a\n  min_coverage_percentage: 90\n",
+			expectContent: "---\nmin_coverage_percentage: 40\npackages:\n- name: a\n" +
+				"  min_coverage_percentage: 95\n",
+		},
+		{
+			description: "comments around the marker",
+			content:     "# generated\n\n--- # config\n# rules\npackages:\n- name: a\n  min_coverage_percentage: 90\n",
+			expectContent: "# generated\n\n--- # config\n# rules\nmin_coverage_percentage: 40\npackages:\n" +
+				"- name: a\n  min_coverage_percentage: 95\n",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			out, changes, err := Ratchet([]byte(tc.content), map[string]float64{"a": 95, "b": 40}, 0)
+			g.Expect(err).To(BeNil())
+			g.Expect(changes).To(HaveLen(2))
+			g.Expect(string(out)).To(Equal(tc.expectContent))
+		})
+	}
+}
+
+func Test_Ratchet_flow_style(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	content := []byte("packages: [{name: foo, min_coverage_percentage: 10}]\n")
+
+	_, _, err := Ratchet(content, map[string]float64{"foo": 50}, 0)
+	g.Expect(err).ToNot(BeNil())
+}
+
+func Test_RaiseEntries(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	content := []byte("min_coverage_percentage: 5\npackages:\n- name: foo\n  min_coverage_percentage: 90\n")
+
+	out, changes, err := RaiseEntries(content, map[int]float64{-1: 66.66, 0: 50})
+	g.Expect(err).To(BeNil())
+	g.Expect(changes).To(Equal([]Change{{Entry: -1, Old: 5, New: 66.66}}))
+	g.Expect(string(out)).To(Equal(
+		"min_coverage_percentage: 66.66\npackages:\n- name: foo\n  min_coverage_percentage: 90\n",
+	))
+
+	_, _, err = RaiseEntries(content, map[int]float64{1: 50})
+	g.Expect(err).ToNot(BeNil())
+}
@@ -0,0 +1,256 @@
+// Copyright © 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Validation holds the problems found in a config file. Errors make the config
+// unusable or not do what was meant, warnings are likely mistakes.
+type Validation struct {
+	Errors   []string
+	Warnings []string
+}
+
+var unknownFieldRe = regexp.MustCompile(`^line (\d+): field (\S+) not found in type (\S+)$`)
+
+// knownKeys maps the name of each config type as it appears in yaml errors to
+// the keys it accepts
+var knownKeys = func() map[string][]string {
+	keys := make(map[string][]string)
+
+	for _, v := range []interface{}{ConfigFile{}, ConfigPackage{}, ConfigFunction{}, ConfigFileRule{}, Waiver{}} {
+		t := reflect.TypeOf(v)
+
+		for i := 0; i < t.NumField(); i++ {
+			tag := t.Field(i).Tag.Get("yaml")
+			if tag == "" {
+				continue
+			}
+
+			keys[t.String()] = append(keys[t.String()], strings.Split(tag, ",")[0])
+		}
+	}
+
+	return keys
+}()
+
+// Validate checks content strictly: unknown and duplicate keys, percentages
+// outside of 0-100 and invalid patterns are errors. If packages is not nil,
+// package entries and waivers which match none of packages are warnings.
+func Validate(content []byte, packages []string) Validation {
+	var v Validation
+
+	cfg := ConfigFile{}
+	if err := yaml.UnmarshalStrict(content, &cfg); err != nil {
+		typeErr, ok := err.(*yaml.TypeError)
+		if !ok {
+			v.Errors = append(v.Errors, err.Error())
+			return v
+		}
+
+		for _, msg := range typeErr.Errors {
+			v.Errors = append(v.Errors, describeYAMLError(msg))
+		}
+	}
+
+	if err := cfg.compile(); err != nil {
+		v.Errors = append(v.Errors, err.Error())
+	}
+
+	v.Errors = append(v.Errors, checkRanges(cfg)...)
+
+	if packages != nil {
+		v.Warnings = unmatchedRules(cfg, packages)
+	}
+
+	return v
+}
+
+// unmatchedRules returns a warning for each package entry and waiver of cfg
+// which matches none of packages
+func unmatchedRules(cfg ConfigFile, packages []string) []string {
+	var warnings []string
+
+	for i, p := range cfg.Packages {
+		m, err := newPackageMatcher(p.Name)
+		if err != nil {
+			continue
+		}
+
+		if !matchesAny(m, packages) {
+			warnings = append(warnings, fmt.Sprintf("packages[%v] %q matches no package", i, p.Name))
+		}
+	}
+
+	for i, w := range cfg.Waivers {
+		m, err := newPackageMatcher(w.Package)
+		if w.Package == "" || err != nil {
+			continue
+		}
+
+		if !matchesAny(m, packages) {
+			warnings = append(warnings, fmt.Sprintf("waivers[%v] %q matches no package", i, w.Package))
+		}
+	}
+
+	return warnings
+}
+
+func matchesAny(m packageMatcher, packages []string) bool {
+	for _, pkg := range packages {
+		if m.match(pkg) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// describeYAMLError adds a suggestion to errors about unknown keys
+func describeYAMLError(msg string) string {
+	m := unknownFieldRe.FindStringSubmatch(msg)
+	if m == nil {
+		return msg
+	}
+
+	out := fmt.Sprintf("line %v: unknown key %v", m[1], m[2])
+
+	if s, ok := suggest(m[2], knownKeys[m[3]]); ok {
+		out += fmt.Sprintf(", did you mean %v?", s)
+	}
+
+	return out
+}
+
+func checkRanges(cfg ConfigFile) []string {
+	errs := make([]string, 0)
+
+	check := func(key string, val float64) {
+		if val < 0 || val > 100 {
+			errs = append(errs, fmt.Sprintf("%v %v is not between 0 and 100", key, val))
+		}
+	}
+
+	checkMax := func(key string, val float64) {
+		if val < 0 {
+			errs = append(errs, fmt.Sprintf("%v %v is negative", key, val))
+		}
+	}
+
+	checkCounts := func(prefix string, maxUncovered *int64, minCovered int64) {
+		if maxUncovered != nil {
+			checkMax(prefix+"max_uncovered_statements", float64(*maxUncovered))
+		}
+
+		checkMax(prefix+"min_covered_statements", float64(minCovered))
+	}
+
+	check("min_coverage_percentage", cfg.MinCoveragePercentage)
+	check("min_branch_coverage_percentage", cfg.MinBranchCoveragePercentage)
+	check("min_line_coverage_percentage", cfg.MinLineCoveragePercentage)
+	check("min_function_coverage_percentage", cfg.MinFunctionCoveragePercentage)
+	check("min_api_coverage_percentage", cfg.MinAPICoveragePercentage)
+	checkMax("max_crap_score", cfg.MaxCRAPScore)
+	checkCounts("", cfg.MaxUncoveredStatements, cfg.MinCoveredStatements)
+
+	for i, p := range cfg.Packages {
+		prefix := fmt.Sprintf("packages[%v].", i)
+
+		check(prefix+"min_coverage_percentage", p.MinCoveragePercentage)
+		check(prefix+"min_branch_coverage_percentage", p.MinBranchCoveragePercentage)
+		check(prefix+"min_line_coverage_percentage", p.MinLineCoveragePercentage)
+		check(prefix+"min_function_coverage_percentage", p.MinFunctionCoveragePercentage)
+		check(prefix+"min_api_coverage_percentage", p.MinAPICoveragePercentage)
+		checkMax(prefix+"max_crap_score", p.MaxCRAPScore)
+		checkCounts(prefix, p.MaxUncoveredStatements, p.MinCoveredStatements)
+
+		for j, f := range p.Functions {
+			check(fmt.Sprintf("%vfunctions[%v].min_coverage_percentage", prefix, j), f.MinCoveragePercentage)
+		}
+	}
+
+	for i, f := range cfg.Files {
+		check(fmt.Sprintf("files[%v].min_coverage_percentage", i), f.MinCoveragePercentage)
+	}
+
+	return errs
+}
+
+// suggest returns the candidate closest to key if it is close enough to be a
+// likely typo
+func suggest(key string, candidates []string) (string, bool) {
+	best := ""
+	bestDist := -1
+
+	for _, c := range candidates {
+		d := editDistance(key, c)
+		if bestDist == -1 || d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+
+	limit := len(key) / 3
+	if limit < 2 {
+		limit = 2
+	}
+
+	return best, bestDist != -1 && bestDist <= limit
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+
+	if c < a {
+		a = c
+	}
+
+	return a
+}
@@ -0,0 +1,139 @@
+// Copyright 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Validate(t *testing.T) {
+	type testcase struct {
+		content        string
+		packages       []string
+		expectErrors   []string
+		expectWarnings []string
+	}
+
+	testCases := map[string]testcase{
+		"valid config": {
+			content: `
+min_coverage_percentage: 25
+packages:
+- name: example.com/svc/...
+  min_coverage_percentage: 66.6
+`,
+			packages: []string{"example.com/svc/billing"},
+		},
+		"unknown key with a suggestion": {
+			content: `
+min_coverage_percentage: 25
+packages:
+- name: example.com/svc
+  mininum_coverage_percentage: 66.6
+`,
+			expectErrors: []string{
+				"line 5: unknown key mininum_coverage_percentage, did you mean min_coverage_percentage?",
+			},
+		},
+		"unknown key without a suggestion": {
+			content:      "threshold: 10\n",
+			expectErrors: []string{"line 1: unknown key threshold"},
+		},
+		"percentages out of range": {
+			content: `
+min_coverage_percentage: 120
+max_crap_score: -1
+min_covered_statements: -3
+packages:
+- name: example.com/svc
+  min_coverage_percentage: -5
+  max_uncovered_statements: -1
+  functions:
+  - name: Charge
+    min_coverage_percentage: 101
+`,
+			expectErrors: []string{
+				"min_coverage_percentage 120 is not between 0 and 100",
+				"max_crap_score -1 is negative",
+				"min_covered_statements -3 is negative",
+				"packages[0].min_coverage_percentage -5 is not between 0 and 100",
+				"packages[0].max_uncovered_statements -1 is negative",
+				"packages[0].functions[0].min_coverage_percentage 101 is not between 0 and 100",
+			},
+		},
+		"package entries which match nothing": {
+			content: `
+packages:
+- name: example.com/svc/billing
+- name: example.com/old/...
+`,
+			packages:       []string{"example.com/svc/billing"},
+			expectWarnings: []string{`packages[1] "example.com/old/..." matches no package`},
+		},
+		"waivers": {
+			content: `
+waivers:
+- package: example.com/svc/billing
+  reason: payment provider sandbox is down
+  owner: alice
+  expires: 2026-12-31
+- package: example.com/old/...
+  reason: being replaced
+  onwer: bob
+  expires: 2026-12-31
+`,
+			packages: []string{"example.com/svc/billing"},
+			expectErrors: []string{
+				"line 9: unknown key onwer, did you mean owner?",
+				"waivers[1] needs a reason and an owner",
+			},
+			expectWarnings: []string{`waivers[1] "example.com/old/..." matches no package`},
+		},
+		"not yaml": {
+			content:      "meow",
+			expectErrors: []string{"line 1: cannot unmarshal !!str `meow` into config.ConfigFile"},
+		},
+		"invalid pattern": {
+			content: "packages:\n- name: ^example.com/(svc\n",
+			expectErrors: []string{
+				"invalid package pattern ^example.com/(svc error parsing regexp: missing closing ): `^example.com/(svc`",
+			},
+		},
+	}
+
+	for desc := range testCases {
+		tc := testCases[desc]
+		t.Run(desc, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			v := Validate([]byte(tc.content), tc.packages)
+			g.Expect(v.Errors).To(ConsistOf(tc.expectErrors))
+			g.Expect(v.Warnings).To(ConsistOf(tc.expectWarnings))
+		})
+	}
+}
+
+func Test_suggest(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, ok := suggest("max_crap", []string{"name", "max_crap_score"})
+	g.Expect(ok).To(BeFalse())
+	g.Expect(s).To(Equal("max_crap_score"))
+
+	s, ok = suggest("pakages", []string{"packages", "files"})
+	g.Expect(ok).To(BeTrue())
+	g.Expect(s).To(Equal("packages"))
+}
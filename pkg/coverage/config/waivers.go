@@ -0,0 +1,137 @@
+// Copyright © 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const waiverDateLayout = "2006-01-02"
+
+// Waiver excuses the failures of the packages matching Package, or only those
+// of their functions matching Function, until the end of the day Expires. An
+// empty Package matches every package.
+type Waiver struct {
+	Package  string `yaml:"package,omitempty"`
+	Function string `yaml:"function,omitempty"`
+	Reason   string `yaml:"reason"`
+	Owner    string `yaml:"owner"`
+	Expires  string `yaml:"expires"`
+
+	packageMatcher  *packageMatcher
+	functionMatcher *packageMatcher
+	expires         time.Time
+}
+
+func (w Waiver) String() string {
+	return fmt.Sprintf("waiver by %v until %v: %v", w.Owner, w.Expires, w.Reason)
+}
+
+// compile checks the fields of w and keeps its matchers and expiry date
+func (w *Waiver) compile() error {
+	if w.Package == "" && w.Function == "" {
+		return fmt.Errorf("needs a package or function pattern")
+	}
+
+	if w.Reason == "" || w.Owner == "" {
+		return fmt.Errorf("needs a reason and an owner")
+	}
+
+	expires, err := time.Parse(waiverDateLayout, w.Expires)
+	if err != nil {
+		return fmt.Errorf("expires %q is not a date like 2006-01-02", w.Expires)
+	}
+
+	w.expires = expires
+
+	if w.Package != "" {
+		m, err := newPackageMatcher(w.Package)
+		if err != nil {
+			return err
+		}
+
+		w.packageMatcher = &m
+	}
+
+	if w.Function != "" {
+		m, err := newFunctionMatcher(w.Function)
+		if err != nil {
+			return err
+		}
+
+		w.functionMatcher = &m
+	}
+
+	return nil
+}
+
+// Matches reports whether w applies to function of pkg. An empty function
+// stands for the package itself, which only waivers without a Function
+// pattern apply to.
+func (w Waiver) Matches(pkg, function string) bool {
+	if !w.MatchesPackage(pkg) {
+		return false
+	}
+
+	if w.functionMatcher == nil {
+		return true
+	}
+
+	return function != "" && w.functionMatcher.match(function)
+}
+
+// MatchesPackage reports whether w applies to pkg or any of its functions
+func (w Waiver) MatchesPackage(pkg string) bool {
+	return w.packageMatcher == nil || w.packageMatcher.match(pkg)
+}
+
+// DaysLeft returns the number of days from the date of now until w expires,
+// which is 0 on the day it expires and negative once it has expired
+func (w Waiver) DaysLeft(now time.Time) int {
+	today, _ := time.Parse(waiverDateLayout, now.Format(waiverDateLayout))
+
+	return int(w.expires.Sub(today).Hours() / 24)
+}
+
+// MatchWaiver returns the first entry of Waivers which applies to function of
+// pkg and hasn't expired by now, along with its index. In a merged config
+// waivers of nearer files come first.
+func (c ConfigFile) MatchWaiver(pkg, function string, now time.Time) (Waiver, int, bool) {
+	for i, w := range c.Waivers {
+		if w.expires.IsZero() && w.compile() != nil {
+			continue
+		}
+
+		if w.DaysLeft(now) >= 0 && w.Matches(pkg, function) {
+			return w, i, true
+		}
+	}
+
+	return Waiver{}, -1, false
+}
+
+// WaiverRule describes entry i of Waivers like PackageRule
+func (c ConfigFile) WaiverRule(i int) string {
+	w := c.Waivers[i]
+
+	name := w.Package
+	if w.Function != "" {
+		name = strings.TrimSpace(w.Package + " " + w.Function)
+	}
+
+	return describeRule("waivers", i, name, c.waiverOrigins)
+}
@@ -0,0 +1,99 @@
+// Copyright 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_ConfigFile_MatchWaiver(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cfg, err := ParseConfigFile([]byte(`
+waivers:
+- package: example.com/svc/billing
+  function: Charge*
+  reason: payment provider sandbox is down
+  owner: alice
+  expires: 2026-12-31
+- package: example.com/svc/legacy/...
+  reason: being replaced
+  owner: bob
+  expires: 2026-10-18
+- function: (*Client).Close
+  reason: no test double for the connection
+  owner: carol
+  expires: 2026-01-01
+`))
+	g.Expect(err).To(BeNil())
+
+	now := time.Date(2026, 10, 18, 23, 59, 0, 0, time.Local)
+
+	testCases := []struct {
+		pkg      string
+		function string
+		expected int
+	}{
+		{pkg: "example.com/svc/billing", function: "ChargeCard", expected: 0},
+		{pkg: "example.com/svc/billing", expected: -1},
+		{pkg: "example.com/svc/billing", function: "Refund", expected: -1},
+		{pkg: "example.com/svc/legacy/db", expected: 1},
+		{pkg: "example.com/svc/legacy/db", function: "Open", expected: 1},
+		{pkg: "example.com/svc/api", function: "(*Client).Close", expected: -1},
+	}
+
+	for _, tc := range testCases {
+		_, i, ok := cfg.MatchWaiver(tc.pkg, tc.function, now)
+		g.Expect(i).To(Equal(tc.expected), "%v %v", tc.pkg, tc.function)
+		g.Expect(ok).To(Equal(tc.expected != -1))
+	}
+
+	// the waiver for legacy still applies on the day it expires but not after
+	_, _, ok := cfg.MatchWaiver("example.com/svc/legacy/db", "", now.Add(time.Minute))
+	g.Expect(ok).To(BeFalse())
+
+	g.Expect(cfg.Waivers[0].DaysLeft(now)).To(Equal(74))
+	g.Expect(cfg.Waivers[1].DaysLeft(now)).To(Equal(0))
+	g.Expect(cfg.Waivers[2].DaysLeft(now)).To(Equal(-290))
+	g.Expect(cfg.WaiverRule(0)).To(Equal(`waivers[0] "example.com/svc/billing Charge*"`))
+	g.Expect(cfg.WaiverRule(2)).To(Equal(`waivers[2] "(*Client).Close"`))
+	g.Expect(cfg.Waivers[0].String()).To(Equal("waiver by alice until 2026-12-31: payment provider sandbox is down"))
+}
+
+func Test_ParseConfigFile_invalid_waivers(t *testing.T) {
+	testCases := map[string]string{
+		"no pattern":   "- reason: r\n  owner: o\n  expires: 2026-12-31",
+		"no reason":    "- package: a\n  owner: o\n  expires: 2026-12-31",
+		"no owner":     "- package: a\n  reason: r\n  expires: 2026-12-31",
+		"no expiry":    "- package: a\n  reason: r\n  owner: o",
+		"bad date":     "- package: a\n  reason: r\n  owner: o\n  expires: 31/12/2026",
+		"bad pattern":  "- package: ^a(\n  reason: r\n  owner: o\n  expires: 2026-12-31",
+		"bad function": "- function: ^a(\n  reason: r\n  owner: o\n  expires: 2026-12-31",
+	}
+
+	for desc, waivers := range testCases {
+		desc, waivers := desc, waivers
+		t.Run(desc, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			_, err := ParseConfigFile([]byte("waivers:\n" + waivers + "\n"))
+			g.Expect(err).ToNot(BeNil())
+			g.Expect(err.Error()).To(HavePrefix("waivers[0] "))
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright © 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+const context = 3
+
+type op struct {
+	kind byte
+	line string
+	a, b int
+}
+
+// Unified returns a unified diff of a and b by line, with 3 lines of context.
+// It returns an empty string if they are equal. It is meant for small files
+// like configs, its cost grows with the product of the line counts.
+func Unified(aName, bName string, a, b []byte) string {
+	if string(a) == string(b) {
+		return ""
+	}
+
+	ops := lineOps(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+
+	fmt.Fprintf(&out, "--- %v\n+++ %v\n", aName, bName)
+
+	for start := 0; start < len(ops); {
+		if ops[start].kind == ' ' {
+			start++
+			continue
+		}
+
+		// a hunk runs from the first change until more than two contexts of
+		// unchanged lines follow the last one
+		first := start - context
+		if first < 0 {
+			first = 0
+		}
+
+		last := start
+
+		for i := start; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				last = i
+			} else if i-last > 2*context {
+				break
+			}
+		}
+
+		end := last + context + 1
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		writeHunk(&out, ops[first:end])
+
+		start = end
+	}
+
+	return out.String()
+}
+
+func writeHunk(out *strings.Builder, ops []op) {
+	aStart, bStart, aLen, bLen := ops[0].a, ops[0].b, 0, 0
+
+	for _, o := range ops {
+		if o.kind != '+' {
+			aLen++
+		}
+
+		if o.kind != '-' {
+			bLen++
+		}
+	}
+
+	fmt.Fprintf(out, "@@ -%v +%v @@\n", hunkRange(aStart, aLen), hunkRange(bStart, bLen))
+
+	for _, o := range ops {
+		fmt.Fprintf(out, "%c%v\n", o.kind, o.line)
+	}
+}
+
+// hunkRange formats the 1 based start and length of a hunk side
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%v,0", start)
+	}
+
+	if length == 1 {
+		return fmt.Sprintf("%v", start+1)
+	}
+
+	return fmt.Sprintf("%v,%v", start+1, length)
+}
+
+func splitLines(content []byte) []string {
+	s := strings.TrimSuffix(string(content), "\n")
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, "\n")
+}
+
+// lineOps returns the edit script from a to b based on their longest common
+// subsequence. a and b of each op are the indexes of the line in a and b, or
+// of the next one for lines only on the other side.
+func lineOps(a, b []string) []op {
+	lcs := lcsTable(a, b)
+	ops := make([]op, 0, len(a)+len(b))
+	i, j := 0, 0
+
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, op{kind: ' ', line: a[i], a: i, b: j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, op{kind: '-', line: a[i], a: i, b: j})
+			i++
+		default:
+			ops = append(ops, op{kind: '+', line: b[j], a: i, b: j})
+			j++
+		}
+	}
+
+	return ops
+}
+
+// lcsTable returns the length of the longest common subsequence of a[i:] and
+// b[j:] for each i and j
+func lcsTable(a, b []string) [][]int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	return lcs
+}
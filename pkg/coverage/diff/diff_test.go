@@ -0,0 +1,56 @@
+// Copyright 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Unified(t *testing.T) {
+	type testcase struct {
+		a      string
+		b      string
+		expect string
+	}
+
+	testCases := map[string]testcase{
+		"equal": {a: "foo\n", b: "foo\n", expect: ""},
+		"new file": {
+			a:      "",
+			b:      "foo\nbar\n",
+			expect: "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+foo\n+bar\n",
+		},
+		"change in the middle": {
+			a:      "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			b:      "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			expect: "--- a\n+++ b\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		"separate hunks": {
+			a:      "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n",
+			b:      "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\neleven\n",
+			expect: "--- a\n+++ b\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -8,4 +8,4 @@\n 8\n 9\n 10\n-11\n+eleven\n",
+		},
+	}
+
+	for desc := range testCases {
+		tc := testCases[desc]
+		t.Run(desc, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			g.Expect(Unified("a", "b", []byte(tc.a), []byte(tc.b))).To(Equal(tc.expect))
+		})
+	}
+}
@@ -16,13 +16,14 @@ package files
 
 import (
 	"fmt"
-	log "github.com/sirupsen/logrus"
-	"go/build"
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/tools/go/packages"
 )
 
 func SetSrcPath(args []string) string {
@@ -66,88 +67,225 @@ func (d dirsToIgnore) Includes(dir string) bool {
 	return false
 }
 
-func FilesForPath(dir string, ignoreDirs dirsToIgnore) ([]string, error) {
-	base := filepath.Base(dir)
-	if base == "..." {
+// IncludesAny reports whether any directory between root and dir should be ignored
+func (d dirsToIgnore) IncludesAny(root, dir string) bool {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return false
+	}
+
+	for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+		if d.Includes(part) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ProjectFiles are the non-test go files of the packages matching a path
+type ProjectFiles struct {
+	// Files are compiled into the packages for the current build configuration
+	Files []string
+	// Excluded are ignored by the go tool because of build constraints
+	Excluded []string
+}
+
+const excludeAllMsg = "build constraints exclude all Go files in "
+
+// FilesForPath loads the packages in dir using the go tool and returns the
+// paths on disk of their files. If dir ends with "..." the packages in all
+// sub directories are loaded as well. buildFlags should match the flags used
+// when running the tests.
+func FilesForPath(dir string, ignoreDirs dirsToIgnore, buildFlags []string) (ProjectFiles, error) {
+	pattern := "."
+	if filepath.Base(dir) == "..." {
 		dir = filepath.Dir(dir)
+		pattern = "./..."
 	}
 
 	fi, err := os.Stat(dir)
 	if err != nil {
-		return nil, err
+		return ProjectFiles{}, err
 	}
 
 	if !fi.IsDir() {
-		return nil, fmt.Errorf("path must be a directory")
+		return ProjectFiles{}, fmt.Errorf("path must be a directory")
 	}
 
-	if base == "..." {
-		return recusiveFilesForPath(dir, ignoreDirs)
+	cfg := &packages.Config{
+		Mode:       packages.NeedName | packages.NeedFiles,
+		Dir:        dir,
+		BuildFlags: buildFlags,
 	}
 
-	return filesForDir(dir)
+	pkgs, err := packages.Load(cfg, pattern)
+	if err != nil {
+		return ProjectFiles{}, fmt.Errorf("could not load packages for %v %v", dir, err)
+	}
+
+	pf, pkgDirs, err := packageFiles(dir, pkgs, ignoreDirs)
+	if err != nil {
+		return ProjectFiles{}, err
+	}
+
+	if pattern == "./..." {
+		// the go tool silently skips directories where every file is excluded
+		// when matching a wildcard so they need to be found separately
+		excluded, err := excludedPackageFiles(dir, pkgDirs, ignoreDirs)
+		if err != nil {
+			return ProjectFiles{}, err
+		}
+
+		pf.Excluded = append(pf.Excluded, excluded...)
+	}
+
+	sort.Strings(pf.Files)
+	sort.Strings(pf.Excluded)
+
+	log.Debugf("files for %v %v excluded %v", dir, pf.Files, pf.Excluded)
+
+	return pf, nil
 }
 
-func recusiveFilesForPath(dir string, ignoreDirs dirsToIgnore) ([]string, error) {
-	goPath := build.Default.GOPATH
-	files := make([]string, 0)
+// packageFiles returns the files of pkgs which are not in ignoreDirs and the
+// directories of all of pkgs
+func packageFiles(
+	dir string,
+	pkgs []*packages.Package,
+	ignoreDirs dirsToIgnore,
+) (ProjectFiles, map[string]bool, error) {
+	pf := ProjectFiles{Files: make([]string, 0)}
+	pkgDirs := make(map[string]bool, len(pkgs))
+
+	for _, pkg := range pkgs {
+		pkgDir, ok := packageDir(pkg)
+		if !ok {
+			log.Debugf("could not find directory for package %v %v", pkg.PkgPath, pkg.Errors)
+			continue
+		}
+
+		pkgDirs[pkgDir] = true
+
+		if ignoreDirs.IncludesAny(dir, pkgDir) {
+			continue
+		}
 
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		for _, e := range pkg.Errors {
+			log.Debugf("error loading package %v %v", pkg.PkgPath, e)
+		}
+
+		pf.Files = append(pf.Files, pkg.GoFiles...)
+
+		excluded, err := excludedFiles(pkgDir, pkg.GoFiles)
+		if err != nil {
+			return ProjectFiles{}, nil, err
+		}
+
+		pf.Excluded = append(pf.Excluded, excluded...)
+	}
+
+	return pf, pkgDirs, nil
+}
+
+func packageDir(pkg *packages.Package) (string, bool) {
+	if len(pkg.GoFiles) > 0 {
+		return filepath.Dir(pkg.GoFiles[0]), true
+	}
+
+	for _, e := range pkg.Errors {
+		if i := strings.Index(e.Msg, excludeAllMsg); i >= 0 {
+			return strings.TrimSpace(e.Msg[i+len(excludeAllMsg):]), true
+		}
+	}
+
+	return "", false
+}
+
+// excludedPackageFiles walks root the same way the go tool matches "./..."
+// and returns the files of directories which were not loaded as packages.
+func excludedPackageFiles(root string, pkgDirs map[string]bool, ignoreDirs dirsToIgnore) ([]string, error) {
+	var excluded []string
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
-			fmt.Printf("could not access path %q: %v\n", path, err)
 			return err
 		}
 
-		if info.IsDir() && ignoreDirs.Includes(info.Name()) {
+		if !info.IsDir() {
+			return nil
+		}
+
+		if path != root && skipDir(path, ignoreDirs) {
 			return filepath.SkipDir
 		}
 
-		if info.Mode().IsRegular() {
-			if regexp.MustCompile(".go$").Match([]byte(path)) {
-				if regexp.MustCompile("_test.go$").Match([]byte(path)) {
-					return nil
-				}
-				path = strings.TrimPrefix(path, fmt.Sprintf("%v/", filepath.Join(goPath, "src")))
-				files = append(files, path)
-			}
+		if pkgDirs[path] {
+			return nil
 		}
+
+		files, err := excludedFiles(path, nil)
+		if err != nil {
+			return err
+		}
+
+		excluded = append(excluded, files...)
+
 		return nil
 	})
 
-	log.Debugf("files for %v %v", dir, files)
+	return excluded, err
+}
+
+// skipDir reports whether the go tool leaves the directory at path out when
+// matching "./..."
+func skipDir(path string, ignoreDirs dirsToIgnore) bool {
+	name := filepath.Base(path)
+	if name == "testdata" || name == "vendor" || strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".") {
+		return true
+	}
+
+	if ignoreDirs.Includes(name) {
+		return true
+	}
+
+	// nested modules are not part of this module
+	_, err := os.Stat(filepath.Join(path, "go.mod"))
 
-	return files, err
+	return err == nil
 }
 
-func filesForDir(dir string) ([]string, error) {
-	goPath := build.Default.GOPATH
+// excludedFiles returns the non-test go files in dir which are not part of
+// the package. Files starting with _ or . are always ignored by the go tool
+// so they are not reported.
+func excludedFiles(dir string, goFiles []string) ([]string, error) {
+	included := make(map[string]bool, len(goFiles))
+	for _, f := range goFiles {
+		included[f] = true
+	}
 
 	fileInfos, err := ioutil.ReadDir(dir)
 	if err != nil {
 		return nil, err
 	}
 
-	files := make([]string, 0)
+	var excluded []string
 
 	for _, fi := range fileInfos {
-		if fi.IsDir() {
+		name := fi.Name()
+		if !fi.Mode().IsRegular() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
 			continue
 		}
 
-		if fi.Mode().IsRegular() {
-			if regexp.MustCompile(".go$").Match([]byte(fi.Name())) {
-				if regexp.MustCompile("_test.go$").Match([]byte(fi.Name())) {
-					continue
-				}
+		if strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".") {
+			continue
+		}
 
-				path := filepath.Join(dir, fi.Name())
-				path = strings.TrimPrefix(path, fmt.Sprintf("%v/", filepath.Join(goPath, "src")))
-				files = append(files, path)
-			}
+		path := filepath.Join(dir, name)
+		if !included[path] {
+			excluded = append(excluded, path)
 		}
 	}
 
-	log.Debugf("files for %v %v", dir, files)
-
-	return files, err
+	return excluded, nil
 }
@@ -73,30 +73,39 @@ func Test_FilesForPath(t *testing.T) {
 		t.FailNow()
 	}
 
-	err = os.Mkdir(filepath.Join(dir, "meow"), 0777)
-	if err != nil {
-		t.Errorf("could not create temp dir %v", err)
-		t.FailNow()
+	srcFiles := map[string]string{
+		"go.mod":                    "module example.com/foo\n",
+		"bar.go":                    "package foo\n",
+		"bar_test.go":               "package foo\n",
+		"tagged.go":                 "// +build integration\n\npackage foo\n",
+		"meow/foo.go":               "package meow\n",
+		"integration/foo.go":        "// +build integration\n\npackage integration\n",
+		"testdata/foo.go":           "package testdata\n",
+		"_hidden/foo.go":            "package hidden\n",
+		"vendor/example.com/x/x.go": "package x\n",
 	}
 
-	err = ioutil.WriteFile(filepath.Join(dir, "meow", "foo.go"), []byte(`meow`), 0644)
-	if err != nil {
-		t.Errorf("could not create temp file %v", err)
-		t.FailNow()
-	}
-
-	err = ioutil.WriteFile(filepath.Join(dir, "bar.go"), []byte(`meow`), 0644)
-	if err != nil {
-		t.Errorf("could not create temp file %v", err)
-		t.FailNow()
+	for name, content := range srcFiles {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Errorf("could not create temp dir %v", err)
+			t.FailNow()
+		}
+
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Errorf("could not create temp file %v", err)
+			t.FailNow()
+		}
 	}
 
 	type testcase struct {
-		description       string
-		expectErr         bool
-		dir               string
-		ignoreDirs        []string
-		expectedFileCount int
+		description      string
+		expectErr        bool
+		dir              string
+		ignoreDirs       []string
+		buildFlags       []string
+		expectedFiles    []string
+		expectedExcluded []string
 	}
 
 	testCases := []testcase{
@@ -111,14 +120,29 @@ func Test_FilesForPath(t *testing.T) {
 			expectErr:   true,
 		},
 		{
-			description:       "valid directory path, no recursion",
-			dir:               dir,
-			expectedFileCount: 1,
+			description:      "valid directory path, no recursion",
+			dir:              dir,
+			expectedFiles:    []string{"bar.go"},
+			expectedExcluded: []string{"tagged.go"},
+		},
+		{
+			description:      "valid directory path, with recursion",
+			dir:              filepath.Join(dir, "..."),
+			expectedFiles:    []string{"bar.go", "meow/foo.go"},
+			expectedExcluded: []string{"integration/foo.go", "tagged.go"},
+		},
+		{
+			description:   "valid directory path, with recursion and build tags",
+			dir:           filepath.Join(dir, "..."),
+			buildFlags:    []string{"-tags=integration"},
+			expectedFiles: []string{"bar.go", "integration/foo.go", "meow/foo.go", "tagged.go"},
 		},
 		{
-			description:       "valid directory path, with recursion",
-			dir:               filepath.Join(dir, "..."),
-			expectedFileCount: 2,
+			description:      "valid directory path, with recursion and ignored dir",
+			dir:              filepath.Join(dir, "..."),
+			ignoreDirs:       []string{"meow"},
+			expectedFiles:    []string{"bar.go"},
+			expectedExcluded: []string{"integration/foo.go", "tagged.go"},
 		},
 	}
 
@@ -126,13 +150,30 @@ func Test_FilesForPath(t *testing.T) {
 		tc := testCases[i]
 		t.Run(tc.description, func(t *testing.T) {
 			g := NewGomegaWithT(t)
-			files, err := FilesForPath(tc.dir, tc.ignoreDirs)
+			pf, err := FilesForPath(tc.dir, tc.ignoreDirs, tc.buildFlags)
 			if tc.expectErr {
 				g.Expect(err).ToNot(BeNil())
 			} else {
 				g.Expect(err).To(BeNil())
-				g.Expect(files).To(HaveLen(tc.expectedFileCount))
+				g.Expect(relPaths(t, dir, pf.Files)).To(Equal(tc.expectedFiles))
+				g.Expect(relPaths(t, dir, pf.Excluded)).To(Equal(tc.expectedExcluded))
 			}
 		})
 	}
 }
+
+func relPaths(t *testing.T, dir string, paths []string) []string {
+	var out []string
+
+	for _, p := range paths {
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			t.Errorf("could not get relative path %v", err)
+			t.FailNow()
+		}
+
+		out = append(out, filepath.ToSlash(rel))
+	}
+
+	return out
+}
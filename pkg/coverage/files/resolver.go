@@ -0,0 +1,158 @@
+// Copyright © 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const goModFile = "go.mod"
+
+// PathResolver translates between paths on disk and the import path style
+// file names (e.g. github.com/foo/bar/baz.go) which are written to coverage
+// profiles.
+type PathResolver struct {
+	// Root is the directory on disk which corresponds to ImportRoot
+	Root string
+	// ImportRoot is the module path for Root, it is empty when Root is GOPATH/src
+	ImportRoot string
+}
+
+// NewPathResolver returns a PathResolver for the module containing srcPath.
+// If srcPath is not inside of a module the resolver falls back to GOPATH/src.
+func NewPathResolver(srcPath string) (PathResolver, error) {
+	if filepath.Base(srcPath) == "..." {
+		srcPath = filepath.Dir(srcPath)
+	}
+
+	absPath, err := filepath.Abs(srcPath)
+	if err != nil {
+		return PathResolver{}, err
+	}
+
+	gopathResolver := PathResolver{Root: filepath.Join(build.Default.GOPATH, "src")}
+
+	modDir, ok := findModuleRoot(absPath)
+	if !ok {
+		log.Debugf("no %v found for %v, resolving paths against %v", goModFile, absPath, gopathResolver.Root)
+		return gopathResolver, nil
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(modDir, goModFile))
+	if err != nil {
+		return PathResolver{}, err
+	}
+
+	modPath := modulePath(content)
+	if modPath == "" {
+		return PathResolver{}, fmt.Errorf("no module path found in %v", filepath.Join(modDir, goModFile))
+	}
+
+	log.Debugf("resolving paths for module %v at %v", modPath, modDir)
+
+	return PathResolver{Root: modDir, ImportRoot: modPath}, nil
+}
+
+// ImportPath returns the import path style name for the file or directory at
+// filePath. Paths outside of Root are returned unchanged.
+func (r PathResolver) ImportPath(filePath string) string {
+	if r.Root == "" {
+		return filePath
+	}
+
+	rel, err := filepath.Rel(r.Root, filePath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filePath
+	}
+
+	if rel == "." {
+		return r.ImportRoot
+	}
+
+	return path.Join(r.ImportRoot, filepath.ToSlash(rel))
+}
+
+// FilePath returns the path on disk for the import path style name
+// importPath. It is the inverse of ImportPath.
+func (r PathResolver) FilePath(importPath string) string {
+	if filepath.IsAbs(importPath) {
+		return importPath
+	}
+
+	if r.ImportRoot == "" {
+		return filepath.Join(r.Root, filepath.FromSlash(importPath))
+	}
+
+	if importPath == r.ImportRoot {
+		return r.Root
+	}
+
+	if strings.HasPrefix(importPath, r.ImportRoot+"/") {
+		rel := strings.TrimPrefix(importPath, r.ImportRoot+"/")
+		return filepath.Join(r.Root, filepath.FromSlash(rel))
+	}
+
+	return importPath
+}
+
+func findModuleRoot(dir string) (string, bool) {
+	for {
+		if fi, err := os.Stat(filepath.Join(dir, goModFile)); err == nil && !fi.IsDir() {
+			return dir, true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+
+		dir = parent
+	}
+}
+
+// modulePath returns the path from the module directive of a go.mod file
+func modulePath(content []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "module") {
+			continue
+		}
+
+		line = strings.TrimSpace(strings.TrimPrefix(line, "module"))
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+
+		if unquoted, err := strconv.Unquote(line); err == nil {
+			line = unquoted
+		}
+
+		return line
+	}
+
+	return ""
+}
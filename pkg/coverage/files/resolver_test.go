@@ -0,0 +1,124 @@
+// Copyright © 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_NewPathResolver(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Errorf("couldnt create temp dir %v", err)
+		t.FailNow()
+	}
+
+	modDir := filepath.Join(dir, "svc")
+	if err := os.MkdirAll(filepath.Join(modDir, "pkg", "x"), 0777); err != nil {
+		t.Errorf("could not create temp dir %v", err)
+		t.FailNow()
+	}
+
+	goMod := []byte("// comment\nmodule \"example.com/svc\" // trailing\n\ngo 1.13\n")
+	if err := ioutil.WriteFile(filepath.Join(modDir, "go.mod"), goMod, 0644); err != nil {
+		t.Errorf("could not create temp file %v", err)
+		t.FailNow()
+	}
+
+	type testcase struct {
+		description string
+		srcPath     string
+		expected    PathResolver
+	}
+
+	testCases := []testcase{
+		{
+			description: "module root",
+			srcPath:     modDir,
+			expected:    PathResolver{Root: modDir, ImportRoot: "example.com/svc"},
+		},
+		{
+			description: "nested package with recursion",
+			srcPath:     filepath.Join(modDir, "pkg", "x", "..."),
+			expected:    PathResolver{Root: modDir, ImportRoot: "example.com/svc"},
+		},
+		{
+			description: "outside of a module",
+			srcPath:     dir,
+			expected:    PathResolver{Root: filepath.Join(build.Default.GOPATH, "src")},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			r, err := NewPathResolver(tc.srcPath)
+			g.Expect(err).To(BeNil())
+			g.Expect(r).To(Equal(tc.expected))
+		})
+	}
+}
+
+func Test_PathResolver(t *testing.T) {
+	type testcase struct {
+		description string
+		resolver    PathResolver
+		filePath    string
+		importPath  string
+	}
+
+	testCases := []testcase{
+		{
+			description: "module file",
+			resolver:    PathResolver{Root: "/home/foo/svc", ImportRoot: "example.com/svc"},
+			filePath:    "/home/foo/svc/pkg/x/x.go",
+			importPath:  "example.com/svc/pkg/x/x.go",
+		},
+		{
+			description: "module root",
+			resolver:    PathResolver{Root: "/home/foo/svc", ImportRoot: "example.com/svc"},
+			filePath:    "/home/foo/svc",
+			importPath:  "example.com/svc",
+		},
+		{
+			description: "gopath file",
+			resolver:    PathResolver{Root: "/go/src"},
+			filePath:    "/go/src/github.com/foo/bar/bar.go",
+			importPath:  "github.com/foo/bar/bar.go",
+		},
+		{
+			description: "file outside of root",
+			resolver:    PathResolver{Root: "/home/foo/svc", ImportRoot: "example.com/svc"},
+			filePath:    "/home/foo/other/other.go",
+			importPath:  "/home/foo/other/other.go",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			g.Expect(tc.resolver.ImportPath(tc.filePath)).To(Equal(tc.importPath))
+			g.Expect(tc.resolver.FilePath(tc.importPath)).To(Equal(tc.filePath))
+		})
+	}
+}
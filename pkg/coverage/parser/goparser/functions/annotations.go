@@ -0,0 +1,239 @@
+// Copyright 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package functions
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	directiveIgnore      = "gocheckcov:ignore"
+	directiveIgnoreNext  = "gocheckcov:ignore-next"
+	directiveIgnoreStart = "gocheckcov:ignore-start"
+	directiveIgnoreEnd   = "gocheckcov:ignore-end"
+)
+
+// Exclusion is a range of lines left out of coverage by a gocheckcov:ignore
+// annotation
+type Exclusion struct {
+	StartLine int
+	EndLine   int
+	Reason    string
+}
+
+func (e Exclusion) contains(line int) bool {
+	return line >= e.StartLine && line <= e.EndLine
+}
+
+// generatedComment is the comment marking generated files, see
+// https://golang.org/s/generatedcode
+var generatedComment = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// isGenerated reports whether f has the generated code comment before its
+// package clause
+func isGenerated(f *ast.File) bool {
+	for _, group := range f.Comments {
+		if group.Pos() >= f.Package {
+			break
+		}
+
+		for _, c := range group.List {
+			if generatedComment.MatchString(c.Text) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+type directive struct {
+	name   string
+	reason string
+	pos    token.Position
+	group  *ast.CommentGroup
+}
+
+// collectExclusions returns the ranges of f excluded by annotations. An ignore
+// in the doc comment of a function excludes the function, ignore-next excludes
+// the statement which follows it and ignore-start excludes the lines up to the
+// matching ignore-end. Any text after the annotation is its reason. Unknown or
+// misplaced annotations are logged and ignored.
+func collectExclusions(f *ast.File, fset *token.FileSet) []Exclusion {
+	directives := parseDirectives(f, fset)
+	if len(directives) == 0 {
+		return nil
+	}
+
+	exclusions := make([]Exclusion, 0, len(directives))
+
+	var open *directive
+
+	for i := range directives {
+		d := directives[i]
+
+		switch d.name {
+		case directiveIgnore, directiveIgnoreNext:
+			if e, ok := exclusionFor(f, fset, d); ok {
+				exclusions = append(exclusions, e)
+			}
+		case directiveIgnoreStart:
+			if open != nil {
+				warnDirective(d, fmt.Sprintf("it is inside of %v at %v", open.name, open.pos))
+				continue
+			}
+
+			open = &d
+		case directiveIgnoreEnd:
+			if open == nil {
+				warnDirective(d, fmt.Sprintf("it has no matching %v", directiveIgnoreStart))
+				continue
+			}
+
+			exclusions = append(exclusions, Exclusion{
+				StartLine: open.pos.Line,
+				EndLine:   d.pos.Line,
+				Reason:    open.reason,
+			})
+			open = nil
+		default:
+			warnDirective(d, "it is not a known annotation")
+		}
+	}
+
+	if open != nil {
+		warnDirective(*open, fmt.Sprintf("it has no matching %v", directiveIgnoreEnd))
+	}
+
+	return exclusions
+}
+
+// exclusionFor returns the range excluded by an ignore or ignore-next
+// annotation, the function it documents or the statement which follows it
+func exclusionFor(f *ast.File, fset *token.FileSet, d directive) (Exclusion, bool) {
+	var node ast.Node
+
+	if d.name == directiveIgnore {
+		decl := funcDeclForDoc(f, d.group)
+		if decl == nil {
+			warnDirective(d, "it must be in the doc comment of a function")
+			return Exclusion{}, false
+		}
+
+		node = decl
+	} else {
+		stmt := nextStmt(f, fset, d.pos)
+		if stmt == nil {
+			warnDirective(d, "it is not followed by a statement")
+			return Exclusion{}, false
+		}
+
+		node = stmt
+	}
+
+	return Exclusion{
+		StartLine: fset.Position(node.Pos()).Line,
+		EndLine:   fset.Position(node.End()).Line,
+		Reason:    d.reason,
+	}, true
+}
+
+func warnDirective(d directive, why string) {
+	log.Warnf("ignoring %v at %v, %v", d.name, d.pos, why)
+}
+
+// parseDirectives returns the gocheckcov directives in the comments of f in
+// source order. Like go directives they are line comments with no space after
+// the slashes.
+func parseDirectives(f *ast.File, fset *token.FileSet) []directive {
+	var directives []directive
+
+	for _, group := range f.Comments {
+		for _, c := range group.List {
+			text := strings.TrimPrefix(c.Text, "//")
+			if text == c.Text || !strings.HasPrefix(text, "gocheckcov:") {
+				continue
+			}
+
+			name := text
+			reason := ""
+
+			if i := strings.IndexAny(text, " \t"); i >= 0 {
+				name = text[:i]
+				reason = strings.TrimSpace(text[i:])
+			}
+
+			directives = append(directives, directive{
+				name:   name,
+				reason: reason,
+				pos:    fset.Position(c.Pos()),
+				group:  group,
+			})
+		}
+	}
+
+	return directives
+}
+
+func funcDeclForDoc(f *ast.File, group *ast.CommentGroup) *ast.FuncDecl {
+	for _, decl := range f.Decls {
+		if fd, ok := decl.(*ast.FuncDecl); ok && fd.Doc == group {
+			return fd
+		}
+	}
+
+	return nil
+}
+
+// nextStmt returns the first statement after pos in the innermost block
+// containing pos
+func nextStmt(f *ast.File, fset *token.FileSet, pos token.Position) ast.Stmt {
+	var list []ast.Stmt
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		if n == nil || !before(fset.Position(n.Pos()), pos) || !before(pos, fset.Position(n.End())) {
+			return false
+		}
+
+		switch x := n.(type) {
+		case *ast.BlockStmt:
+			list = x.List
+		case *ast.CaseClause:
+			list = x.Body
+		case *ast.CommClause:
+			list = x.Body
+		}
+
+		return true
+	})
+
+	for _, stmt := range list {
+		if !before(fset.Position(stmt.Pos()), pos) {
+			return stmt
+		}
+	}
+
+	return nil
+}
+
+func before(a, b token.Position) bool {
+	return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+}
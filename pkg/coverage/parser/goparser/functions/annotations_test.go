@@ -0,0 +1,305 @@
+// Copyright 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package functions
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"os"
+	"testing"
+
+	"github.com/cvgw/gocheckcov/pkg/coverage/parser/goparser/statements"
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+)
+
+func Test_collectExclusions(t *testing.T) {
+	type testcase struct {
+		description      string
+		src              string
+		expectWarning    string
+		expectExclusions []Exclusion
+	}
+
+	testCases := []testcase{
+		{
+			description: "no annotations",
+			src: `package foo
+
+// Foo does foo
+func Foo() {}
+`,
+		},
+		{
+			description: "ignore function",
+			src: `package foo
+
+// Foo does foo
+//gocheckcov:ignore only used in debugging
+func Foo() {
+	println()
+}
+`,
+			expectExclusions: []Exclusion{{StartLine: 5, EndLine: 7, Reason: "only used in debugging"}},
+		},
+		{
+			description: "ignore next statement",
+			src: `package foo
+
+func Foo(err error) error {
+	if err != nil {
+		//gocheckcov:ignore-next
+		return err
+	}
+
+	return nil
+}
+`,
+			expectExclusions: []Exclusion{{StartLine: 6, EndLine: 6}},
+		},
+		{
+			description: "ignore a range",
+			src: `package foo
+
+func Foo(x int) {
+	//gocheckcov:ignore-start unreachable
+	if x < 0 {
+		panic(x)
+	}
+	//gocheckcov:ignore-end
+}
+`,
+			expectExclusions: []Exclusion{{StartLine: 4, EndLine: 8, Reason: "unreachable"}},
+		},
+		{
+			description: "ignore outside of a function doc comment",
+			src: `package foo
+
+func Foo() {
+	//gocheckcov:ignore
+	println()
+}
+`,
+			expectWarning: "ignoring gocheckcov:ignore at foo.go:4:2",
+		},
+		{
+			description: "ignore-next without a statement",
+			src: `package foo
+
+func Foo() {
+	//gocheckcov:ignore-next
+}
+`,
+			expectWarning: "ignoring gocheckcov:ignore-next at foo.go:4:2",
+		},
+		{
+			description: "ignore-next at the end of a block",
+			src: `package foo
+
+func Foo() {
+	panic("foo")
+	//gocheckcov:ignore-next
+}
+
+func Bar() {
+	panic("bar")
+}
+`,
+			expectWarning: "ignoring gocheckcov:ignore-next at foo.go:5:2",
+		},
+		{
+			description: "ignore-next in a case clause",
+			src: `package foo
+
+func Foo(x int) int {
+	switch x {
+	case 0:
+		//gocheckcov:ignore-next
+		panic(x)
+	default:
+		return x
+	}
+}
+`,
+			expectExclusions: []Exclusion{{StartLine: 7, EndLine: 7}},
+		},
+		{
+			description: "ignore-start without ignore-end",
+			src: `package foo
+
+func Foo() {
+	//gocheckcov:ignore-start
+	println()
+}
+`,
+			expectWarning: "ignoring gocheckcov:ignore-start at foo.go:4:2",
+		},
+		{
+			description: "ignore-end without ignore-start",
+			src: `package foo
+
+func Foo() {
+	println()
+	//gocheckcov:ignore-end
+}
+`,
+			expectWarning: "ignoring gocheckcov:ignore-end at foo.go:5:2",
+		},
+		{
+			description: "unknown annotation",
+			src: `package foo
+
+//gocheckcov:skip
+func Foo() {}
+`,
+			expectWarning: "ignoring gocheckcov:skip at foo.go:3:1",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			fset := token.NewFileSet()
+			f, err := parser.ParseFile(fset, "foo.go", tc.src, parser.ParseComments)
+			g.Expect(err).To(BeNil())
+
+			var out bytes.Buffer
+			log.SetOutput(&out)
+			defer log.SetOutput(os.Stderr)
+
+			exclusions := collectExclusions(f, fset)
+			if tc.expectWarning != "" {
+				g.Expect(out.String()).To(ContainSubstring(tc.expectWarning))
+				g.Expect(exclusions).To(BeEmpty())
+			} else {
+				g.Expect(out.String()).To(BeEmpty())
+				g.Expect(exclusions).To(Equal(tc.expectExclusions))
+			}
+		})
+	}
+}
+
+func Test_isGenerated(t *testing.T) {
+	type testcase struct {
+		description string
+		src         string
+		expected    bool
+	}
+
+	testCases := []testcase{
+		{
+			description: "generated",
+			src:         "// Code generated by MockGen. DO NOT EDIT.\n\npackage foo\n",
+			expected:    true,
+		},
+		{
+			description: "generated after a build constraint",
+			src:         "// +build linux\n\n// Code generated by stringer; DO NOT EDIT.\n\npackage foo\n",
+			expected:    true,
+		},
+		{
+			description: "not generated",
+			src:         "// Package foo does things.\npackage foo\n",
+			expected:    false,
+		},
+		{
+			description: "comment missing the final period",
+			src:         "// Code generated by hand. DO NOT EDIT\n\npackage foo\n",
+			expected:    false,
+		},
+		{
+			description: "comment after the package clause",
+			src:         "package foo\n\n// Code generated by MockGen. DO NOT EDIT.\n",
+			expected:    false,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			fset := token.NewFileSet()
+			f, err := parser.ParseFile(fset, "foo.go", tc.src, parser.ParseComments)
+			g.Expect(err).To(BeNil())
+			g.Expect(isGenerated(f)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func Test_CollectFunctions_Exclusions(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	src := `package foo
+
+func Foo(x int) int {
+	if x > 0 {
+		return x
+	} else {
+		//gocheckcov:ignore-next
+		panic(x)
+	}
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "foo.go", src, parser.ParseComments)
+	g.Expect(err).To(BeNil())
+
+	funcs, err := CollectFunctions(f, fset, "foo.go")
+	g.Expect(err).To(BeNil())
+	g.Expect(funcs).To(HaveLen(1))
+
+	ignored := make([]bool, 0, len(funcs[0].Statements))
+	for _, stmt := range funcs[0].Statements {
+		ignored = append(ignored, stmt.Ignored)
+	}
+
+	g.Expect(ignored).To(Equal([]bool{false, false, true}))
+	g.Expect(funcs[0].Exclusions).To(Equal([]Exclusion{{StartLine: 8, EndLine: 8}}))
+	g.Expect(funcs[0].Branches).To(HaveLen(1))
+	g.Expect(funcs[0].Branches[0].Arms).To(HaveLen(1))
+}
+
+func Test_CollectFunctions_ExcludedArms(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	src := `package foo
+
+func Foo(x int) int {
+	switch x {
+	case 0:
+		//gocheckcov:ignore-next
+		panic(x)
+	case 1:
+		x++
+	}
+
+	return x
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "foo.go", src, parser.ParseComments)
+	g.Expect(err).To(BeNil())
+
+	funcs, err := CollectFunctions(f, fset, "foo.go")
+	g.Expect(err).To(BeNil())
+	g.Expect(funcs).To(HaveLen(1))
+
+	// without the runs of the ignored case the implicit default can't be
+	// worked out
+	g.Expect(funcs[0].Branches).To(HaveLen(1))
+	g.Expect(funcs[0].Branches[0].Arms).To(Equal([]statements.Arm{{Line: 9, Col: 3}}))
+}
@@ -15,8 +15,10 @@
 package functions
 
 import (
+	"fmt"
 	"go/ast"
 	"go/token"
+	"strings"
 
 	"github.com/cvgw/gocheckcov/pkg/coverage/parser/goparser/statements"
 	log "github.com/sirupsen/logrus"
@@ -25,55 +27,35 @@ import (
 func CollectFunctions(f *ast.File, fset *token.FileSet, filePath string) ([]Function, error) {
 	functions := []Function{}
 
+	exclusions := collectExclusions(f, fset)
+	generated := isGenerated(f)
+
 	for i := range f.Decls {
 		switch x := f.Decls[i].(type) {
 		case *ast.FuncDecl:
 			name := x.Name.Name
+			exported := ast.IsExported(name)
 
-			start := fset.Position(x.Pos())
-			end := fset.Position(x.End())
-			startLine := start.Line
-			startCol := start.Column
-			endLine := end.Line
-			endCol := end.Column
-			f := Function{
-				Name:        name,
-				StartLine:   startLine,
-				StartCol:    startCol,
-				EndLine:     endLine,
-				EndCol:      endCol,
-				SrcPath:     filePath,
-				StartOffset: start.Offset,
-				EndOffset:   end.Offset,
+			if x.Recv != nil && len(x.Recv.List) > 0 {
+				name = fmt.Sprintf("%v.%v", receiverType(x.Recv.List[0].Type), name)
+				exported = exported && ast.IsExported(receiverBaseType(x.Recv.List[0].Type))
 			}
 
-			sc := &statements.StmtCollector{}
-			if err := sc.Collect(x.Body, fset); err != nil {
+			fn, err := newFunction(name, x.Pos(), x.End(), x.Body, exclusions, fset, filePath)
+			if err != nil {
 				return nil, err
 			}
 
-			stmts := sc.Statements
-			log.Debugf("statements for function %v %v", f.Name, stmts)
-			convertedStmts := make([]statements.Statement, 0, len(stmts))
-
-			for _, stmnt := range stmts {
-				start := fset.Position(stmnt.Pos())
-				end := fset.Position(stmnt.End())
-				startLine := start.Line
-				startCol := start.Column
-				endLine := end.Line
-				endCol := end.Column
-				s := statements.Statement{
-					StartLine: int64(startLine),
-					StartCol:  int64(startCol),
-					EndLine:   int64(endLine),
-					EndCol:    int64(endCol),
-				}
-				convertedStmts = append(convertedStmts, s)
+			fn.Exported = exported
+			fn.Generated = generated
+
+			closures, err := collectClosures(&fn, "%v.func%v", x.Body, exclusions, fset, filePath)
+			if err != nil {
+				return nil, err
 			}
 
-			f.Statements = convertedStmts
-			functions = append(functions, f)
+			functions = append(functions, fn)
+			functions = append(functions, closures...)
 		}
 	}
 
@@ -81,3 +63,221 @@ func CollectFunctions(f *ast.File, fset *token.FileSet, filePath string) ([]Func
 
 	return functions, nil
 }
+
+// receiverType returns the type of a method receiver the way the runtime
+// names methods, e.g. (*Client) or List[K, V].
+func receiverType(expr ast.Expr) string {
+	switch x := expr.(type) {
+	case *ast.StarExpr:
+		return fmt.Sprintf("(*%v)", receiverType(x.X))
+	case *ast.ParenExpr:
+		return receiverType(x.X)
+	case *ast.IndexExpr:
+		return fmt.Sprintf("%v[%v]", receiverType(x.X), receiverType(x.Index))
+	case *ast.Ident:
+		return x.Name
+	default:
+		if base, indices, ok := indexListExpr(expr); ok {
+			params := make([]string, 0, len(indices))
+			for _, index := range indices {
+				params = append(params, receiverType(index))
+			}
+
+			return fmt.Sprintf("%v[%v]", receiverType(base), strings.Join(params, ", "))
+		}
+
+		return fmt.Sprintf("%T", expr)
+	}
+}
+
+// receiverBaseType returns the name of the type of a method receiver without
+// pointer or type parameters.
+func receiverBaseType(expr ast.Expr) string {
+	switch x := expr.(type) {
+	case *ast.StarExpr:
+		return receiverBaseType(x.X)
+	case *ast.ParenExpr:
+		return receiverBaseType(x.X)
+	case *ast.IndexExpr:
+		return receiverBaseType(x.X)
+	case *ast.Ident:
+		return x.Name
+	default:
+		if base, _, ok := indexListExpr(expr); ok {
+			return receiverBaseType(base)
+		}
+
+		return ""
+	}
+}
+
+func newFunction(
+	name string,
+	pos, endPos token.Pos,
+	body *ast.BlockStmt,
+	exclusions []Exclusion,
+	fset *token.FileSet,
+	filePath string,
+) (Function, error) {
+	start := fset.Position(pos)
+	end := fset.Position(endPos)
+	startLine := start.Line
+	startCol := start.Column
+	endLine := end.Line
+	endCol := end.Column
+	f := Function{
+		Name:        name,
+		StartLine:   startLine,
+		StartCol:    startCol,
+		EndLine:     endLine,
+		EndCol:      endCol,
+		SrcPath:     filePath,
+		StartOffset: start.Offset,
+		EndOffset:   end.Offset,
+	}
+
+	for _, e := range exclusions {
+		if e.StartLine <= endLine && e.EndLine >= startLine {
+			f.Exclusions = append(f.Exclusions, e)
+		}
+	}
+
+	sc := &statements.StmtCollector{}
+	if err := sc.Collect(body, fset); err != nil {
+		return Function{}, err
+	}
+
+	stmts := sc.Statements
+	log.Debugf("statements for function %v %v", f.Name, stmts)
+	convertedStmts := make([]statements.Statement, 0, len(stmts))
+
+	for _, stmnt := range stmts {
+		start := fset.Position(stmnt.Pos())
+		end := fset.Position(stmnt.End())
+		startLine := start.Line
+		startCol := start.Column
+		endLine := end.Line
+		endCol := end.Column
+		s := statements.Statement{
+			StartOffset: int64(start.Offset),
+			StartLine:   int64(startLine),
+			StartCol:    int64(startCol),
+			EndOffset:   int64(end.Offset),
+			EndLine:     int64(endLine),
+			EndCol:      int64(endCol),
+			Ignored:     f.excluded(startLine),
+		}
+		convertedStmts = append(convertedStmts, s)
+	}
+
+	f.Statements = convertedStmts
+	f.Branches = f.filterBranches(sc.Branches)
+	f.Complexity = complexity(body, func(pos token.Pos) bool {
+		return f.excluded(fset.Position(pos).Line)
+	})
+
+	return f, nil
+}
+
+// complexity returns the cyclomatic complexity of body, one plus the number of
+// decisions in it. Function literals are functions of their own so they are
+// not counted, and neither is code left out by an annotation.
+func complexity(body *ast.BlockStmt, excluded func(token.Pos) bool) int {
+	c := 1
+
+	if body == nil {
+		return c
+	}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		if _, ok := n.(*ast.FuncLit); ok || n != nil && excluded(n.Pos()) {
+			return false
+		}
+
+		if isDecision(n) {
+			c++
+		}
+
+		return true
+	})
+
+	return c
+}
+
+// isDecision reports whether n is a branch point counted by complexity
+func isDecision(n ast.Node) bool {
+	switch x := n.(type) {
+	case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+		return true
+	case *ast.CaseClause:
+		return x.List != nil
+	case *ast.CommClause:
+		return x.Comm != nil
+	case *ast.BinaryExpr:
+		return x.Op == token.LAND || x.Op == token.LOR
+	}
+
+	return false
+}
+
+// collectClosures returns a Function for each function literal in body, named
+// after parent the same way the compiler names them (Outer.func1,
+// Outer.func1.1). The ranges of the literals declared directly in body are
+// recorded on parent so their statements are not counted twice. A closure
+// starts after the opening brace of its body because that is where cover ends
+// the block of the enclosing statement.
+func collectClosures(
+	parent *Function,
+	nameFormat string,
+	body *ast.BlockStmt,
+	exclusions []Exclusion,
+	fset *token.FileSet,
+	filePath string,
+) ([]Function, error) {
+	if body == nil {
+		return nil, nil
+	}
+
+	var lits []*ast.FuncLit
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.FuncLit); ok {
+			lits = append(lits, lit)
+			// literals nested in this one belong to it
+			return false
+		}
+
+		return true
+	})
+
+	var closures []Function
+
+	for i, lit := range lits {
+		name := fmt.Sprintf(nameFormat, parent.Name, i+1)
+
+		fn, err := newFunction(name, lit.Body.Lbrace+1, lit.End(), lit.Body, exclusions, fset, filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		fn.Exported = parent.Exported
+		fn.Generated = parent.Generated
+
+		nested, err := collectClosures(&fn, "%v.%v", lit.Body, exclusions, fset, filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		parent.Closures = append(parent.Closures, Range{
+			StartLine: fn.StartLine,
+			StartCol:  fn.StartCol,
+			EndLine:   fn.EndLine,
+			EndCol:    fn.EndCol,
+		})
+
+		closures = append(closures, fn)
+		closures = append(closures, nested...)
+	}
+
+	return closures, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+// +build go1.18
+
+package functions
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_CollectFunctions_GenericMethods(t *testing.T) {
+	src := `package foo
+
+type List[T any] struct{}
+
+func (l *List[T]) Len() int { return 0 }
+
+type Pair[K comparable, V any] struct{}
+
+func (p Pair[K, V]) Key() {}
+
+type pair[K comparable, V any] struct{}
+
+func (p *pair[K, V]) Key() {}
+`
+
+	g := NewGomegaWithT(t)
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "foo.go", src, 0)
+	g.Expect(err).To(BeNil())
+
+	funcs, err := CollectFunctions(f, fset, "foo.go")
+	g.Expect(err).To(BeNil())
+
+	exported := make(map[string]bool)
+	for _, fn := range funcs {
+		exported[fn.Name] = fn.Exported
+	}
+
+	g.Expect(exported).To(Equal(map[string]bool{
+		"(*List[T]).Len":    true,
+		"Pair[K, V].Key":    true,
+		"(*pair[K, V]).Key": false,
+	}))
+}
@@ -63,6 +63,222 @@ func Meow(x, y int) bool {
 	g.Expect(funcs).To(HaveLen(1))
 }
 
+func Test_CollectFunctions_Closures(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	src := `package foo
+
+func Outer() {
+	f := func() {
+		g := func() {}
+		g()
+	}
+	f()
+	go func() {}()
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "foo.go", src, 0)
+	g.Expect(err).To(BeNil())
+
+	funcs, err := CollectFunctions(f, fset, "foo.go")
+	g.Expect(err).To(BeNil())
+
+	names := make([]string, 0, len(funcs))
+	for _, fn := range funcs {
+		names = append(names, fn.Name)
+	}
+
+	g.Expect(names).To(Equal([]string{"Outer", "Outer.func1", "Outer.func1.1", "Outer.func2"}))
+
+	g.Expect(funcs[0].Statements).To(HaveLen(3))
+	g.Expect(funcs[0].Closures).To(Equal([]Range{
+		{StartLine: 4, StartCol: 15, EndLine: 7, EndCol: 3},
+		{StartLine: 9, StartCol: 13, EndLine: 9, EndCol: 14},
+	}))
+	g.Expect(funcs[1].Statements).To(HaveLen(2))
+	g.Expect(funcs[1].Closures).To(HaveLen(1))
+	g.Expect(funcs[2].Statements).To(BeEmpty())
+}
+
+func Test_CollectFunctions_Methods(t *testing.T) {
+	src := `package foo
+
+type Client struct{}
+
+func (c *Client) Close() {}
+
+func (Client) Name() string { return "" }
+
+func (*Client) Dial() { _ = func() {} }
+`
+
+	g := NewGomegaWithT(t)
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "foo.go", src, 0)
+	g.Expect(err).To(BeNil())
+
+	funcs, err := CollectFunctions(f, fset, "foo.go")
+	g.Expect(err).To(BeNil())
+
+	names := make([]string, 0, len(funcs))
+	for _, fn := range funcs {
+		names = append(names, fn.Name)
+	}
+
+	g.Expect(names).To(Equal([]string{
+		"(*Client).Close",
+		"Client.Name",
+		"(*Client).Dial",
+		"(*Client).Dial.func1",
+	}))
+}
+
+func Test_CollectFunctions_Complexity(t *testing.T) {
+	src := `package foo
+
+func Empty() {}
+
+func Decisions(xs []int, c chan int) int {
+	n := 0
+	for _, x := range xs {
+		if x > 0 && x < 10 || x == 20 {
+			n++
+		}
+	}
+
+	switch n {
+	case 1, 2:
+	case 3:
+	default:
+	}
+
+	select {
+	case <-c:
+	default:
+	}
+
+	f := func() {
+		if n > 0 {
+		}
+	}
+	f()
+
+	return n
+}
+`
+
+	g := NewGomegaWithT(t)
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "foo.go", src, 0)
+	g.Expect(err).To(BeNil())
+
+	funcs, err := CollectFunctions(f, fset, "foo.go")
+	g.Expect(err).To(BeNil())
+	g.Expect(funcs).To(HaveLen(3))
+
+	g.Expect(funcs[0].Complexity).To(Equal(1))
+	// range, if, &&, ||, two cases and one comm clause
+	g.Expect(funcs[1].Complexity).To(Equal(8))
+	g.Expect(funcs[2].Complexity).To(Equal(2))
+}
+
+func Test_CollectFunctions_ComplexityExcluded(t *testing.T) {
+	src := `package foo
+
+func Foo(x int) int {
+	if x > 0 {
+		return x
+	}
+
+	//gocheckcov:ignore-next can't happen
+	if x < -10 && x > -20 {
+		panic(x)
+	}
+
+	//gocheckcov:ignore-start
+	for x < 0 {
+		x++
+	}
+	//gocheckcov:ignore-end
+
+	return x
+}
+`
+
+	g := NewGomegaWithT(t)
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "foo.go", src, parser.ParseComments)
+	g.Expect(err).To(BeNil())
+
+	funcs, err := CollectFunctions(f, fset, "foo.go")
+	g.Expect(err).To(BeNil())
+	g.Expect(funcs).To(HaveLen(1))
+
+	g.Expect(funcs[0].Complexity).To(Equal(2))
+}
+
+func Test_CollectFunctions_Exported(t *testing.T) {
+	src := `package foo
+
+type Client struct{}
+
+type conn struct{}
+
+func New() { _ = func() {} }
+
+func helper() {}
+
+func (c *Client) Close() {}
+
+func (c *Client) reset() {}
+
+func (c *conn) Close() {}
+`
+
+	g := NewGomegaWithT(t)
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "foo.go", src, 0)
+	g.Expect(err).To(BeNil())
+
+	funcs, err := CollectFunctions(f, fset, "foo.go")
+	g.Expect(err).To(BeNil())
+
+	exported := make(map[string]bool)
+	for _, fn := range funcs {
+		exported[fn.Name] = fn.Exported
+	}
+
+	g.Expect(exported).To(Equal(map[string]bool{
+		"New":             true,
+		"New.func1":       true,
+		"helper":          false,
+		"(*Client).Close": true,
+		"(*Client).reset": false,
+		"(*conn).Close":   false,
+	}))
+}
+
+func Test_CollectFunctions_Generated(t *testing.T) {
+	src := `// Code generated by MockGen. DO NOT EDIT.
+
+package foo
+
+func Mock() { _ = func() {} }
+`
+
+	g := NewGomegaWithT(t)
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "foo.go", src, parser.ParseComments)
+	g.Expect(err).To(BeNil())
+
+	funcs, err := CollectFunctions(f, fset, "foo.go")
+	g.Expect(err).To(BeNil())
+	g.Expect(funcs).To(HaveLen(2))
+	g.Expect(funcs[0].Generated).To(BeTrue())
+	g.Expect(funcs[1].Generated).To(BeTrue())
+}
+
 //func MatchFunc(expected Function) types.GomegaMatcher {
 //  return &funcMatcher{
 //    expected: expected,
@@ -26,4 +26,86 @@ type Function struct {
 	EndLine     int
 	EndCol      int
 	Statements  []statements.Statement
+	Branches    []statements.Branch
+	Complexity  int
+	// Exported is set for exported functions, methods of exported types and
+	// the closures declared in them
+	Exported bool
+	// Exclusions are the annotated ranges which overlap the function, their
+	// statements and branches are not counted
+	Exclusions []Exclusion
+	// Generated is set for functions in files with a "Code generated ... DO
+	// NOT EDIT." header
+	Generated bool
+	// Closures are the function literals declared directly inside of the
+	// function. They are reported as functions of their own so their
+	// statements don't count towards this one.
+	Closures []Range
+}
+
+func (f Function) excluded(line int) bool {
+	for _, e := range f.Exclusions {
+		if e.contains(line) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// filterBranches drops excluded branches and arms, and branches left without
+// arms. The runs of an implicit arm are worked out from the runs of the other
+// arms, so it is dropped along with any of them.
+func (f Function) filterBranches(branches []statements.Branch) []statements.Branch {
+	if len(f.Exclusions) == 0 {
+		return branches
+	}
+
+	out := make([]statements.Branch, 0, len(branches))
+
+	for _, branch := range branches {
+		if f.excluded(int(branch.StartLine)) {
+			continue
+		}
+
+		arms := make([]statements.Arm, 0, len(branch.Arms))
+
+		for _, arm := range branch.Arms {
+			if !f.excluded(int(arm.Line)) {
+				arms = append(arms, arm)
+			}
+		}
+
+		if len(arms) < len(branch.Arms) {
+			arms = explicitArms(arms)
+		}
+
+		if len(arms) == 0 {
+			continue
+		}
+
+		branch.Arms = arms
+		out = append(out, branch)
+	}
+
+	return out
+}
+
+func explicitArms(arms []statements.Arm) []statements.Arm {
+	out := make([]statements.Arm, 0, len(arms))
+
+	for _, arm := range arms {
+		if !arm.Implicit {
+			out = append(out, arm)
+		}
+	}
+
+	return out
+}
+
+type Range struct {
+	StartLine int
+	StartCol  int
+	EndLine   int
+	EndCol    int
 }
@@ -0,0 +1,31 @@
+// Copyright 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+// +build go1.18
+
+package functions
+
+import "go/ast"
+
+// indexListExpr unpacks a generic type instantiated with more than one type
+// parameter, e.g. the Pair[K, V] in func (p Pair[K, V]) Key().
+func indexListExpr(expr ast.Expr) (ast.Expr, []ast.Expr, bool) {
+	x, ok := expr.(*ast.IndexListExpr)
+	if !ok {
+		return nil, nil, false
+	}
+
+	return x.X, x.Indices, true
+}
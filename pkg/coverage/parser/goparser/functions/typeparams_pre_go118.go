@@ -0,0 +1,26 @@
+// Copyright 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !go1.18
+// +build !go1.18
+
+package functions
+
+import "go/ast"
+
+// indexListExpr always reports false since toolchains before Go 1.18 can not
+// parse receivers with more than one type parameter.
+func indexListExpr(expr ast.Expr) (ast.Expr, []ast.Expr, bool) {
+	return nil, nil, false
+}
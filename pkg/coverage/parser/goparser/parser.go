@@ -15,28 +15,34 @@
 package goparser
 
 import (
-	log "github.com/sirupsen/logrus"
 	"go/ast"
 	"go/parser"
 	"go/token"
 	"io/ioutil"
-	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
 )
 
-func NodeFromFilePath(filePath, goSrcPath string, fset *token.FileSet) (*ast.File, error) {
-	pFilePath := filepath.Join(goSrcPath, filePath)
+func NodeFromFilePath(filePath string, fset *token.FileSet) (*ast.File, error) {
+	f, _, err := ParseFile(filePath, fset)
+
+	return f, err
+}
 
-	src, err := ioutil.ReadFile(pFilePath)
+// ParseFile returns the ast for the file at filePath along with its source.
+// Comments are kept so gocheckcov annotations can be read from the ast.
+func ParseFile(filePath string, fset *token.FileSet) (*ast.File, []byte, error) {
+	src, err := ioutil.ReadFile(filePath)
 	if err != nil {
-		log.Debugf("could not read file from profile %v %v", pFilePath, err)
-		return nil, err
+		log.Debugf("could not read file from profile %v %v", filePath, err)
+		return nil, nil, err
 	}
 
-	f, err := parser.ParseFile(fset, pFilePath, src, 0)
+	f, err := parser.ParseFile(fset, filePath, src, parser.ParseComments)
 	if err != nil {
-		log.Debugf("could not parse file %v %v", pFilePath, err)
-		return nil, err
+		log.Debugf("could not parse file %v %v", filePath, err)
+		return nil, nil, err
 	}
 
-	return f, nil
+	return f, src, nil
 }
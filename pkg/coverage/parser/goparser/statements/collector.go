@@ -22,6 +22,7 @@ import (
 
 type StmtCollector struct {
 	Statements []ast.Stmt
+	Branches   []Branch
 }
 
 func (sc *StmtCollector) Collect(s ast.Stmt, fset *token.FileSet) error {
@@ -86,15 +87,9 @@ func (sc *StmtCollector) descend(n ast.Node, fset *token.FileSet) error {
 	case *ast.RangeStmt:
 		err = sc.Collect(s.Body, fset)
 	case *ast.SelectStmt:
-		err = sc.Collect(s.Body, fset)
+		err = sc.handleSelectStmt(s, fset)
 	case *ast.SwitchStmt:
-		if s.Init != nil {
-			if e := sc.Collect(s.Init, fset); e != nil {
-				return e
-			}
-		}
-
-		err = sc.Collect(s.Body, fset)
+		err = sc.handleSwitchStmt(s, fset)
 	case *ast.TypeSwitchStmt:
 		err = sc.handleTypeSwitchStmt(s, fset)
 	}
@@ -102,6 +97,33 @@ func (sc *StmtCollector) descend(n ast.Node, fset *token.FileSet) error {
 	return err
 }
 
+func (sc *StmtCollector) handleSelectStmt(s *ast.SelectStmt, fset *token.FileSet) error {
+	if err := sc.Collect(s.Body, fset); err != nil {
+		return err
+	}
+
+	// a select without default blocks, there is no implicit arm
+	sc.addClauseBranch("select", s, s.Body, false, fset)
+
+	return nil
+}
+
+func (sc *StmtCollector) handleSwitchStmt(s *ast.SwitchStmt, fset *token.FileSet) error {
+	if s.Init != nil {
+		if err := sc.Collect(s.Init, fset); err != nil {
+			return err
+		}
+	}
+
+	if err := sc.Collect(s.Body, fset); err != nil {
+		return err
+	}
+
+	sc.addClauseBranch("switch", s, s.Body, true, fset)
+
+	return nil
+}
+
 func (sc *StmtCollector) handleTypeSwitchStmt(s *ast.TypeSwitchStmt, fset *token.FileSet) error {
 	if s.Init != nil {
 		if err := sc.Collect(s.Init, fset); err != nil {
@@ -117,6 +139,8 @@ func (sc *StmtCollector) handleTypeSwitchStmt(s *ast.TypeSwitchStmt, fset *token
 		return err
 	}
 
+	sc.addClauseBranch("type switch", s, s.Body, true, fset)
+
 	return nil
 }
 
@@ -151,6 +175,18 @@ func (sc *StmtCollector) handleIfStmt(s *ast.IfStmt, fset *token.FileSet) error
 		return err
 	}
 
+	// the else arm must be recorded before handleIfStmtElse moves it
+	arms := []token.Pos{armStart(s.Body.List, s.Body.Lbrace+1)}
+
+	switch e := s.Else.(type) {
+	case *ast.IfStmt:
+		arms = append(arms, e.If)
+	case *ast.BlockStmt:
+		arms = append(arms, armStart(e.List, e.Lbrace+1))
+	}
+
+	sc.addBranch("if", s, arms, s.Else == nil, fset)
+
 	if s.Else != nil {
 		if err := sc.handleIfStmtElse(s, fset); err != nil {
 			return err
@@ -160,6 +196,67 @@ func (sc *StmtCollector) handleIfStmt(s *ast.IfStmt, fset *token.FileSet) error
 	return nil
 }
 
+// addClauseBranch records a branch with an arm for each case or comm clause
+// of body. If withDefault is set and body has no default clause the branch
+// gets an implicit default arm.
+func (sc *StmtCollector) addClauseBranch(
+	kind string,
+	s ast.Stmt,
+	body *ast.BlockStmt,
+	withDefault bool,
+	fset *token.FileSet,
+) {
+	arms := make([]token.Pos, 0, len(body.List))
+	hasDefault := false
+
+	for _, clause := range body.List {
+		switch c := clause.(type) {
+		case *ast.CaseClause:
+			arms = append(arms, armStart(c.Body, c.Colon+1))
+			hasDefault = hasDefault || c.List == nil
+		case *ast.CommClause:
+			arms = append(arms, armStart(c.Body, c.Colon+1))
+		}
+	}
+
+	sc.addBranch(kind, s, arms, withDefault && !hasDefault, fset)
+}
+
+// armStart returns the position of the first statement of an arm. Depending on
+// the go version cover starts the block of an arm there or right after the
+// brace or colon, the first statement is inside of the block either way. Empty
+// arms have a block without statements at start.
+func armStart(list []ast.Stmt, start token.Pos) token.Pos {
+	if len(list) > 0 {
+		return list[0].Pos()
+	}
+
+	return start
+}
+
+// addBranch records a branch for s with an arm starting at each of arms, and
+// an implicit arm at the start of s if implicit is set.
+func (sc *StmtCollector) addBranch(kind string, s ast.Stmt, arms []token.Pos, implicit bool, fset *token.FileSet) {
+	start := fset.Position(s.Pos())
+	b := Branch{
+		Kind:      kind,
+		StartLine: int64(start.Line),
+		StartCol:  int64(start.Column),
+		Arms:      make([]Arm, 0, len(arms)+1),
+	}
+
+	for _, arm := range arms {
+		pos := fset.Position(arm)
+		b.Arms = append(b.Arms, Arm{Line: int64(pos.Line), Col: int64(pos.Column)})
+	}
+
+	if implicit {
+		b.Arms = append(b.Arms, Arm{Line: b.StartLine, Col: b.StartCol, Implicit: true})
+	}
+
+	sc.Branches = append(sc.Branches, b)
+}
+
 func (sc *StmtCollector) handleIfStmtElse(s *ast.IfStmt, fset *token.FileSet) error {
 	// Code copied from go.tools/cmd/cover, to deal with "if x {} else if y {}"
 	// Copied from go.tools/cmd/cover
@@ -16,6 +16,7 @@ package statements
 
 import (
 	"go/ast"
+	"go/parser"
 	"go/token"
 	"testing"
 
@@ -178,3 +179,87 @@ func Test_CollectStatements(t *testing.T) {
 		})
 	}
 }
+
+func Test_CollectBranches(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	src := `package foo
+
+func Foo(n int, c chan int) {
+	if n > 1 {
+		n++
+	} else if n > 0 {
+		n--
+	} else {
+		n = 0
+	}
+
+	switch n {
+	case 1:
+	default:
+		n++
+	}
+
+	select {
+	case <-c:
+	}
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "foo.go", src, 0)
+	g.Expect(err).To(BeNil())
+
+	s := &StmtCollector{}
+	err = s.Collect(f.Decls[0].(*ast.FuncDecl).Body, fset)
+	g.Expect(err).To(BeNil())
+
+	g.Expect(s.Branches).To(Equal([]Branch{
+		{Kind: "if", StartLine: 4, StartCol: 2, Arms: []Arm{{Line: 5, Col: 3}, {Line: 6, Col: 9}}},
+		{Kind: "if", StartLine: 6, StartCol: 9, Arms: []Arm{{Line: 7, Col: 3}, {Line: 9, Col: 3}}},
+		{Kind: "switch", StartLine: 12, StartCol: 2, Arms: []Arm{{Line: 13, Col: 9}, {Line: 15, Col: 3}}},
+		{Kind: "select", StartLine: 18, StartCol: 2, Arms: []Arm{{Line: 19, Col: 11}}},
+	}))
+}
+
+func Test_CollectBranches_implicitArms(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	src := `package foo
+
+func Foo(n int, v interface{}, c chan int) {
+	if n > 0 {
+		n--
+	}
+
+	switch n {
+	case 1:
+		n++
+	}
+
+	switch v.(type) {
+	case int:
+	}
+
+	select {
+	case <-c:
+	}
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "foo.go", src, 0)
+	g.Expect(err).To(BeNil())
+
+	s := &StmtCollector{}
+	err = s.Collect(f.Decls[0].(*ast.FuncDecl).Body, fset)
+	g.Expect(err).To(BeNil())
+
+	g.Expect(s.Branches).To(Equal([]Branch{
+		{Kind: "if", StartLine: 4, StartCol: 2, Arms: []Arm{{Line: 5, Col: 3}, {Line: 4, Col: 2, Implicit: true}}},
+		{Kind: "switch", StartLine: 8, StartCol: 2, Arms: []Arm{{Line: 10, Col: 3}, {Line: 8, Col: 2, Implicit: true}}},
+		{Kind: "type switch", StartLine: 13, StartCol: 2, Arms: []Arm{
+			{Line: 14, Col: 11},
+			{Line: 13, Col: 2, Implicit: true},
+		}},
+		{Kind: "select", StartLine: 17, StartCol: 2, Arms: []Arm{{Line: 18, Col: 11}}},
+	}))
+}
@@ -22,4 +22,26 @@ type Statement struct {
 	EndLine       int64
 	EndCol        int64
 	ExecutedCount int64
+	// Ignored statements are excluded from coverage by an annotation
+	Ignored bool
+}
+
+// Branch is a decision point, an if, switch, type switch or select statement,
+// and the arms that can be taken from it. The missing else of an if and the
+// missing default of a switch or type switch are included as implicit arms.
+type Branch struct {
+	Kind      string
+	StartLine int64
+	StartCol  int64
+	Arms      []Arm
+}
+
+// Arm is the position at which one arm of a Branch starts executing. Implicit
+// arms have no code of their own and are at the start of their Branch, they
+// were taken each time the Branch was reached and none of its other arms was.
+type Arm struct {
+	Line          int64
+	Col           int64
+	ExecutedCount int64
+	Implicit      bool
 }
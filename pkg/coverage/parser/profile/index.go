@@ -0,0 +1,106 @@
+// Copyright © 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package profile
+
+import (
+	"sort"
+
+	"golang.org/x/tools/cover"
+)
+
+type position struct {
+	line int
+	col  int
+}
+
+func (p position) before(other position) bool {
+	return p.line < other.line || p.line == other.line && p.col < other.col
+}
+
+// blockIndex answers which blocks of a profile overlap a range of the source.
+// Blocks are sorted by start and maxEnd holds the furthest end of any block up
+// to each index, so the first candidate can be found with a binary search.
+type blockIndex struct {
+	blocks []cover.ProfileBlock
+	maxEnd []position
+}
+
+func newBlockIndex(blocks []cover.ProfileBlock) blockIndex {
+	sorted := make([]cover.ProfileBlock, len(blocks))
+	copy(sorted, blocks)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return blockStart(sorted[i]).before(blockStart(sorted[j]))
+	})
+
+	maxEnd := make([]position, len(sorted))
+
+	for i, block := range sorted {
+		maxEnd[i] = blockEnd(block)
+		if i > 0 && maxEnd[i].before(maxEnd[i-1]) {
+			maxEnd[i] = maxEnd[i-1]
+		}
+	}
+
+	return blockIndex{blocks: sorted, maxEnd: maxEnd}
+}
+
+// overlapping calls fn for each block which ends after start and starts
+// before end, in order of the block start.
+func (idx blockIndex) overlapping(start, end position, fn func(cover.ProfileBlock)) {
+	first := sort.Search(len(idx.maxEnd), func(i int) bool {
+		return start.before(idx.maxEnd[i])
+	})
+
+	for i := first; i < len(idx.blocks); i++ {
+		block := idx.blocks[i]
+		if !blockStart(block).before(end) {
+			return
+		}
+
+		if start.before(blockEnd(block)) {
+			fn(block)
+		}
+	}
+}
+
+// at returns the block that contains p. A block without statements, like the
+// body of an empty case clause, may start and end at p.
+func (idx blockIndex) at(p position) (cover.ProfileBlock, bool) {
+	next := sort.Search(len(idx.blocks), func(i int) bool {
+		return p.before(blockStart(idx.blocks[i]))
+	})
+
+	for i := next - 1; i >= 0; i-- {
+		block := idx.blocks[i]
+		if p.before(blockEnd(block)) || blockStart(block) == p {
+			return block, true
+		}
+
+		if !p.before(idx.maxEnd[i]) && blockStart(block).before(p) {
+			break
+		}
+	}
+
+	return cover.ProfileBlock{}, false
+}
+
+func blockStart(block cover.ProfileBlock) position {
+	return position{line: block.StartLine, col: block.StartCol}
+}
+
+func blockEnd(block cover.ProfileBlock) position {
+	return position{line: block.EndLine, col: block.EndCol}
+}
@@ -0,0 +1,110 @@
+// Copyright © 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package profile
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"golang.org/x/tools/cover"
+)
+
+func Test_blockIndex_overlapping(t *testing.T) {
+	blocks := []cover.ProfileBlock{
+		{StartLine: 20, StartCol: 2, EndLine: 22, EndCol: 3},
+		{StartLine: 3, StartCol: 10, EndLine: 5, EndCol: 3},
+		{StartLine: 5, StartCol: 3, EndLine: 7, EndCol: 2},
+		{StartLine: 10, StartCol: 1, EndLine: 18, EndCol: 4},
+		{StartLine: 12, StartCol: 1, EndLine: 12, EndCol: 9},
+	}
+
+	type testcase struct {
+		description string
+		start       position
+		end         position
+	}
+
+	testCases := []testcase{
+		{description: "before all blocks", start: position{1, 1}, end: position{2, 1}},
+		{description: "after all blocks", start: position{30, 1}, end: position{40, 1}},
+		{description: "first function", start: position{3, 1}, end: position{7, 2}},
+		{description: "range ending where a block starts", start: position{1, 1}, end: position{3, 10}},
+		{description: "range starting where a block ends", start: position{7, 2}, end: position{9, 1}},
+		{description: "range inside of a long block", start: position{14, 1}, end: position{15, 1}},
+		{description: "everything", start: position{1, 1}, end: position{40, 1}},
+	}
+
+	idx := newBlockIndex(blocks)
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			var expected []cover.ProfileBlock
+
+			for _, block := range idx.blocks {
+				if tc.start.before(blockEnd(block)) && blockStart(block).before(tc.end) {
+					expected = append(expected, block)
+				}
+			}
+
+			var actual []cover.ProfileBlock
+
+			idx.overlapping(tc.start, tc.end, func(block cover.ProfileBlock) {
+				actual = append(actual, block)
+			})
+
+			g.Expect(actual).To(Equal(expected))
+		})
+	}
+}
+
+func Test_blockIndex_at(t *testing.T) {
+	blocks := []cover.ProfileBlock{
+		{StartLine: 3, StartCol: 10, EndLine: 5, EndCol: 3, Count: 1},
+		{StartLine: 6, StartCol: 9, EndLine: 6, EndCol: 9, Count: 2},
+		{StartLine: 7, StartCol: 3, EndLine: 8, EndCol: 4, Count: 3},
+	}
+
+	type testcase struct {
+		description string
+		p           position
+		expectFound bool
+		expectCount int
+	}
+
+	testCases := []testcase{
+		{description: "before all blocks", p: position{1, 1}},
+		{description: "start of a block", p: position{3, 10}, expectFound: true, expectCount: 1},
+		{description: "inside of a block", p: position{4, 1}, expectFound: true, expectCount: 1},
+		{description: "end of a block", p: position{5, 3}},
+		{description: "block without statements", p: position{6, 9}, expectFound: true, expectCount: 2},
+		{description: "between blocks", p: position{6, 20}},
+		{description: "after all blocks", p: position{9, 1}},
+	}
+
+	idx := newBlockIndex(blocks)
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			block, ok := idx.at(tc.p)
+			g.Expect(ok).To(Equal(tc.expectFound))
+			g.Expect(block.Count).To(Equal(tc.expectCount))
+		})
+	}
+}
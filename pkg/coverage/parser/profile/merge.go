@@ -0,0 +1,199 @@
+// Copyright © 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package profile
+
+import (
+	"fmt"
+	"sort"
+
+	"golang.org/x/tools/cover"
+)
+
+const (
+	modeSet    = "set"
+	modeCount  = "count"
+	modeAtomic = "atomic"
+)
+
+// ParseProfiles parses the coverage profile at each of filePaths and merges
+// them into a single set of profiles with one profile per file.
+func ParseProfiles(filePaths []string) ([]*cover.Profile, error) {
+	var merged []*cover.Profile
+
+	for _, filePath := range filePaths {
+		profiles, err := cover.ParseProfiles(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse profiles from %v %v", filePath, err)
+		}
+
+		merged, err = MergeProfiles(merged, profiles)
+		if err != nil {
+			return nil, fmt.Errorf("could not merge profiles from %v %v", filePath, err)
+		}
+	}
+
+	return merged, nil
+}
+
+// MergeProfiles combines the blocks of profiles for the same file. For set
+// mode a block is covered if it is covered in either profile, for count and
+// atomic modes the counts are summed. Profiles in set mode can not be merged
+// with profiles in count or atomic mode.
+func MergeProfiles(into, from []*cover.Profile) ([]*cover.Profile, error) {
+	if err := checkModes(into, from); err != nil {
+		return nil, err
+	}
+
+	byFile := make(map[string]*cover.Profile, len(into)+len(from))
+
+	for _, p := range into {
+		byFile[p.FileName] = copyProfile(p)
+	}
+
+	for _, p := range from {
+		existing, ok := byFile[p.FileName]
+		if !ok {
+			byFile[p.FileName] = copyProfile(p)
+			continue
+		}
+
+		blocks, err := mergeBlocks(existing.Mode, existing.Blocks, p.Blocks)
+		if err != nil {
+			return nil, fmt.Errorf("could not merge blocks for file %v %v", p.FileName, err)
+		}
+
+		existing.Blocks = blocks
+	}
+
+	out := make([]*cover.Profile, 0, len(byFile))
+	for _, p := range byFile {
+		out = append(out, p)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].FileName < out[j].FileName
+	})
+
+	return out, nil
+}
+
+// checkModes returns an error if the mode of any of the profiles can't be
+// merged with the mode of the first one, whether or not they cover the same
+// files
+func checkModes(into, from []*cover.Profile) error {
+	var first *cover.Profile
+
+	for _, profiles := range [][]*cover.Profile{into, from} {
+		for _, p := range profiles {
+			if first == nil {
+				first = p
+				continue
+			}
+
+			if !modesCompatible(first.Mode, p.Mode) {
+				return fmt.Errorf(
+					"can not merge coverage mode %v of file %v with coverage mode %v of file %v",
+					p.Mode,
+					p.FileName,
+					first.Mode,
+					first.FileName,
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+func modesCompatible(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	// count and atomic both record the number of times a block executed
+	return (a == modeCount || a == modeAtomic) && (b == modeCount || b == modeAtomic)
+}
+
+func mergeBlocks(mode string, a, b []cover.ProfileBlock) ([]cover.ProfileBlock, error) {
+	blocks := make([]cover.ProfileBlock, 0, len(a)+len(b))
+	blocks = append(blocks, a...)
+	blocks = append(blocks, b...)
+
+	sort.SliceStable(blocks, func(i, j int) bool {
+		bi, bj := blocks[i], blocks[j]
+		return bi.StartLine < bj.StartLine || bi.StartLine == bj.StartLine && bi.StartCol < bj.StartCol
+	})
+
+	out := make([]cover.ProfileBlock, 0, len(blocks))
+
+	for _, block := range blocks {
+		if len(out) == 0 {
+			out = append(out, block)
+			continue
+		}
+
+		last := &out[len(out)-1]
+
+		if samePosition(*last, block) {
+			addCount(mode, last, block.Count)
+			continue
+		}
+
+		if overlaps(*last, block) {
+			return nil, fmt.Errorf(
+				"block %v.%v,%v.%v overlaps block %v.%v,%v.%v, profiles were likely generated from different sources",
+				block.StartLine, block.StartCol, block.EndLine, block.EndCol,
+				last.StartLine, last.StartCol, last.EndLine, last.EndCol,
+			)
+		}
+
+		out = append(out, block)
+	}
+
+	return out, nil
+}
+
+// addCount adds count to the count of block the way mode counts
+func addCount(mode string, block *cover.ProfileBlock, count int) {
+	if mode != modeSet {
+		block.Count += count
+	} else if count > 0 {
+		block.Count = 1
+	}
+}
+
+// overlaps reports whether a, which starts first, ends after b starts
+func overlaps(a, b cover.ProfileBlock) bool {
+	return a.EndLine > b.StartLine || a.EndLine == b.StartLine && a.EndCol > b.StartCol
+}
+
+func samePosition(a, b cover.ProfileBlock) bool {
+	return a.StartLine == b.StartLine &&
+		a.StartCol == b.StartCol &&
+		a.EndLine == b.EndLine &&
+		a.EndCol == b.EndCol &&
+		a.NumStmt == b.NumStmt
+}
+
+func copyProfile(p *cover.Profile) *cover.Profile {
+	blocks := make([]cover.ProfileBlock, len(p.Blocks))
+	copy(blocks, p.Blocks)
+
+	return &cover.Profile{
+		FileName: p.FileName,
+		Mode:     p.Mode,
+		Blocks:   blocks,
+	}
+}
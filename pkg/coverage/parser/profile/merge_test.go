@@ -0,0 +1,141 @@
+// Copyright © 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package profile
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"golang.org/x/tools/cover"
+)
+
+func Test_MergeProfiles(t *testing.T) {
+	type testcase struct {
+		description string
+		into        []*cover.Profile
+		from        []*cover.Profile
+		expected    []*cover.Profile
+		expectErr   bool
+	}
+
+	block := func(startLine, endLine, count int) cover.ProfileBlock {
+		return cover.ProfileBlock{StartLine: startLine, StartCol: 1, EndLine: endLine, EndCol: 2, NumStmt: 1, Count: count}
+	}
+
+	testCases := []testcase{
+		{
+			description: "distinct files",
+			into:        []*cover.Profile{{FileName: "b.go", Mode: "set", Blocks: []cover.ProfileBlock{block(1, 2, 1)}}},
+			from:        []*cover.Profile{{FileName: "a.go", Mode: "set", Blocks: []cover.ProfileBlock{block(1, 2, 0)}}},
+			expected: []*cover.Profile{
+				{FileName: "a.go", Mode: "set", Blocks: []cover.ProfileBlock{block(1, 2, 0)}},
+				{FileName: "b.go", Mode: "set", Blocks: []cover.ProfileBlock{block(1, 2, 1)}},
+			},
+		},
+		{
+			description: "set mode keeps any covered block",
+			into: []*cover.Profile{
+				{FileName: "a.go", Mode: "set", Blocks: []cover.ProfileBlock{block(1, 2, 1), block(3, 4, 0)}},
+			},
+			from: []*cover.Profile{
+				{FileName: "a.go", Mode: "set", Blocks: []cover.ProfileBlock{block(1, 2, 1), block(3, 4, 1)}},
+			},
+			expected: []*cover.Profile{
+				{FileName: "a.go", Mode: "set", Blocks: []cover.ProfileBlock{block(1, 2, 1), block(3, 4, 1)}},
+			},
+		},
+		{
+			description: "count and atomic modes sum counts",
+			into:        []*cover.Profile{{FileName: "a.go", Mode: "count", Blocks: []cover.ProfileBlock{block(1, 2, 3)}}},
+			from: []*cover.Profile{
+				{FileName: "a.go", Mode: "atomic", Blocks: []cover.ProfileBlock{block(1, 2, 4), block(5, 6, 1)}},
+			},
+			expected: []*cover.Profile{
+				{FileName: "a.go", Mode: "count", Blocks: []cover.ProfileBlock{block(1, 2, 7), block(5, 6, 1)}},
+			},
+		},
+		{
+			description: "set and count modes conflict",
+			into:        []*cover.Profile{{FileName: "a.go", Mode: "set", Blocks: []cover.ProfileBlock{block(1, 2, 1)}}},
+			from:        []*cover.Profile{{FileName: "a.go", Mode: "count", Blocks: []cover.ProfileBlock{block(1, 2, 4)}}},
+			expectErr:   true,
+		},
+		{
+			description: "set and count modes conflict for distinct files",
+			into:        []*cover.Profile{{FileName: "a.go", Mode: "set", Blocks: []cover.ProfileBlock{block(1, 2, 1)}}},
+			from:        []*cover.Profile{{FileName: "b.go", Mode: "count", Blocks: []cover.ProfileBlock{block(1, 2, 4)}}},
+			expectErr:   true,
+		},
+		{
+			description: "overlapping blocks",
+			into:        []*cover.Profile{{FileName: "a.go", Mode: "set", Blocks: []cover.ProfileBlock{block(1, 4, 1)}}},
+			from:        []*cover.Profile{{FileName: "a.go", Mode: "set", Blocks: []cover.ProfileBlock{block(2, 3, 1)}}},
+			expectErr:   true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			merged, err := MergeProfiles(tc.into, tc.from)
+			if tc.expectErr {
+				g.Expect(err).ToNot(BeNil())
+			} else {
+				g.Expect(err).To(BeNil())
+				g.Expect(merged).To(Equal(tc.expected))
+			}
+		})
+	}
+}
+
+func Test_ParseProfiles(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Errorf("could not create temp dir")
+		t.FailNow()
+	}
+
+	unitPath := filepath.Join(dir, "unit.out")
+	unitContent := "mode: count\nfoo/a.go:1.1,2.2 1 2\nfoo/a.go:3.1,4.2 1 0\n"
+
+	if err := ioutil.WriteFile(unitPath, []byte(unitContent), 0644); err != nil {
+		t.Errorf("could not write to temp file %v", err)
+		t.FailNow()
+	}
+
+	e2ePath := filepath.Join(dir, "e2e.out")
+	e2eContent := "mode: count\nfoo/a.go:3.1,4.2 1 5\nfoo/b.go:1.1,2.2 1 1\n"
+
+	if err := ioutil.WriteFile(e2ePath, []byte(e2eContent), 0644); err != nil {
+		t.Errorf("could not write to temp file %v", err)
+		t.FailNow()
+	}
+
+	profiles, err := ParseProfiles([]string{unitPath, e2ePath})
+	g.Expect(err).To(BeNil())
+	g.Expect(profiles).To(HaveLen(2))
+	g.Expect(profiles[0].FileName).To(Equal("foo/a.go"))
+	g.Expect(profiles[0].Blocks).To(HaveLen(2))
+	g.Expect(profiles[0].Blocks[0].Count).To(Equal(2))
+	g.Expect(profiles[0].Blocks[1].Count).To(Equal(5))
+
+	_, err = ParseProfiles([]string{filepath.Join(dir, "missing.out")})
+	g.Expect(err).ToNot(BeNil())
+}
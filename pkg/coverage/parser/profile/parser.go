@@ -16,19 +16,43 @@ package profile
 
 import (
 	"go/token"
+	"math"
 
 	"github.com/cvgw/gocheckcov/pkg/coverage/parser/goparser/functions"
+	"github.com/cvgw/gocheckcov/pkg/coverage/parser/goparser/statements"
 
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/tools/cover"
 )
 
 type FunctionCoverage struct {
-	StatementCount int64
-	CoveredCount   int64
-	Name           string
-	Function       functions.Function
-	Profile        *cover.Profile
+	StatementCount     int64
+	CoveredCount       int64
+	BranchCount        int64
+	CoveredBranchCount int64
+	// LineCount counts the lines on which a statement starts, a line is
+	// covered if any of its statements ran
+	LineCount        int64
+	CoveredLineCount int64
+	Name             string
+	Function         functions.Function
+	Profile          *cover.Profile
+}
+
+// CRAP returns the change risk anti-patterns score of the function, its
+// complexity squared times the share of uncovered statements cubed plus its
+// complexity. Complex functions without tests score high.
+func (fc FunctionCoverage) CRAP() float64 {
+	comp := float64(fc.Function.Complexity)
+
+	uncovered := 1.0
+	if fc.StatementCount > 0 {
+		uncovered = 1 - float64(fc.CoveredCount)/float64(fc.StatementCount)
+	}
+
+	crap := comp*comp*math.Pow(uncovered, 3) + comp
+
+	return math.Round(crap*100) / 100
 }
 
 type Parser struct {
@@ -40,6 +64,11 @@ type Parser struct {
 func (p Parser) RecordFunctionCoverage(functions []functions.Function) []FunctionCoverage {
 	out := make([]FunctionCoverage, 0, len(functions))
 
+	var idx blockIndex
+	if p.Profile != nil {
+		idx = newBlockIndex(p.Profile.Blocks)
+	}
+
 	for _, function := range functions {
 		fc := FunctionCoverage{
 			Name:     function.Name,
@@ -47,20 +76,35 @@ func (p Parser) RecordFunctionCoverage(functions []functions.Function) []Functio
 		}
 
 		if p.Profile != nil {
-			fc = p.recordCoverageHits(fc, function)
+			fc = recordCoverageHits(idx, fc, function)
+			fc.Function.Statements = recordStatementCounts(idx, function.Statements)
+			fc.Function.Branches = recordBranchCounts(idx, function.Branches)
 			fc.Profile = p.Profile
 		}
 
-		if int(fc.StatementCount) != len(function.Statements) {
+		fc.LineCount, fc.CoveredLineCount = lineCounts(fc.Function.Statements)
+
+		for _, branch := range fc.Function.Branches {
+			for _, arm := range branch.Arms {
+				fc.BranchCount++
+				if arm.ExecutedCount > 0 {
+					fc.CoveredBranchCount++
+				}
+			}
+		}
+
+		astCount := countedStatements(function.Statements)
+
+		if fc.StatementCount != astCount {
 			log.Debugf(
 				"function %v statement counts don't match Profile: %v AST: %v",
 				function.Name,
 				fc.StatementCount,
-				len(function.Statements),
+				astCount,
 			)
 
-			if int(fc.StatementCount) == 0 && len(function.Statements) > 0 {
-				fc.StatementCount = int64(len(function.Statements))
+			if fc.StatementCount == 0 && astCount > 0 {
+				fc.StatementCount = astCount
 			}
 		}
 
@@ -70,28 +114,160 @@ func (p Parser) RecordFunctionCoverage(functions []functions.Function) []Functio
 	return out
 }
 
-func (p Parser) recordCoverageHits(fc FunctionCoverage, function functions.Function) FunctionCoverage {
-	for _, block := range p.Profile.Blocks {
-		startLine := function.StartLine
-		startCol := function.StartCol
-		endLine := function.EndLine
-		endCol := function.EndCol
+func recordCoverageHits(idx blockIndex, fc FunctionCoverage, function functions.Function) FunctionCoverage {
+	idx.overlapping(functionStart(function), functionEnd(function), func(block cover.ProfileBlock) {
+		// blocks of closures are counted by the closure itself
+		for _, closure := range function.Closures {
+			if !blockStart(block).before(rangeStart(closure)) && !rangeEnd(closure).before(blockEnd(block)) {
+				return
+			}
+		}
+
+		numStmt := int64(block.NumStmt) - ignoredStatements(block, function.Statements)
+		if numStmt <= 0 {
+			return
+		}
 
-		if block.StartLine > endLine || (block.StartLine == endLine && block.StartCol >= endCol) {
-			// Block starts after the function statement ends
-			continue
+		fc.StatementCount += numStmt
+		if block.Count > 0 {
+			fc.CoveredCount += numStmt
 		}
+	})
 
-		if block.EndLine < startLine || (block.EndLine == startLine && block.EndCol <= startCol) {
-			// Block ends before the function statement starts
+	return fc
+}
+
+// ignoredStatements returns the number of statements starting in block which
+// are excluded by an annotation
+func ignoredStatements(block cover.ProfileBlock, stmts []statements.Statement) int64 {
+	var n int64
+
+	for _, stmt := range stmts {
+		start := position{line: int(stmt.StartLine), col: int(stmt.StartCol)}
+		if stmt.Ignored && !start.before(blockStart(block)) && start.before(blockEnd(block)) {
+			n++
+		}
+	}
+
+	return n
+}
+
+// lineCounts returns the number of lines on which a counted statement starts
+// and how many of them had a statement executed
+func lineCounts(stmts []statements.Statement) (int64, int64) {
+	lines := make(map[int64]bool)
+
+	for _, stmt := range stmts {
+		if stmt.Ignored {
 			continue
 		}
 
-		fc.StatementCount += int64(block.NumStmt)
-		if block.Count > 0 {
-			fc.CoveredCount += int64(block.NumStmt)
+		lines[stmt.StartLine] = lines[stmt.StartLine] || stmt.ExecutedCount > 0
+	}
+
+	var covered int64
+
+	for _, ok := range lines {
+		if ok {
+			covered++
 		}
 	}
 
-	return fc
+	return int64(len(lines)), covered
+}
+
+func countedStatements(stmts []statements.Statement) int64 {
+	var n int64
+
+	for _, stmt := range stmts {
+		if !stmt.Ignored {
+			n++
+		}
+	}
+
+	return n
+}
+
+// recordStatementCounts returns a copy of stmts with the execution count of the
+// block each statement starts in.
+func recordStatementCounts(idx blockIndex, stmts []statements.Statement) []statements.Statement {
+	if stmts == nil {
+		return nil
+	}
+
+	out := make([]statements.Statement, len(stmts))
+
+	for i, stmt := range stmts {
+		start := position{line: int(stmt.StartLine), col: int(stmt.StartCol)}
+		next := position{line: start.line, col: start.col + 1}
+
+		idx.overlapping(start, next, func(block cover.ProfileBlock) {
+			stmt.ExecutedCount += int64(block.Count)
+		})
+
+		out[i] = stmt
+	}
+
+	return out
+}
+
+// recordBranchCounts returns a copy of branches with the execution count of the
+// block each arm starts in. An implicit arm starts in the block which reaches
+// its branch, it ran as often as that block did less the runs of the other
+// arms. A case reached by fallthrough counts as a run, so the implicit arm of
+// such a switch may be undercounted.
+func recordBranchCounts(idx blockIndex, branches []statements.Branch) []statements.Branch {
+	if branches == nil {
+		return nil
+	}
+
+	out := make([]statements.Branch, len(branches))
+
+	for i, branch := range branches {
+		arms := make([]statements.Arm, len(branch.Arms))
+
+		var taken int64
+
+		for j, arm := range branch.Arms {
+			if block, ok := idx.at(position{line: int(arm.Line), col: int(arm.Col)}); ok {
+				arm.ExecutedCount = int64(block.Count)
+			}
+
+			if !arm.Implicit {
+				taken += arm.ExecutedCount
+			}
+
+			arms[j] = arm
+		}
+
+		for j := range arms {
+			if arms[j].Implicit {
+				arms[j].ExecutedCount -= taken
+				if arms[j].ExecutedCount < 0 {
+					arms[j].ExecutedCount = 0
+				}
+			}
+		}
+
+		branch.Arms = arms
+		out[i] = branch
+	}
+
+	return out
+}
+
+func functionStart(function functions.Function) position {
+	return position{line: function.StartLine, col: function.StartCol}
+}
+
+func functionEnd(function functions.Function) position {
+	return position{line: function.EndLine, col: function.EndCol}
+}
+
+func rangeStart(r functions.Range) position {
+	return position{line: r.StartLine, col: r.StartCol}
+}
+
+func rangeEnd(r functions.Range) position {
+	return position{line: r.EndLine, col: r.EndCol}
 }
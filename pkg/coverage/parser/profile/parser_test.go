@@ -21,6 +21,7 @@ import (
 
 	"github.com/cvgw/gocheckcov/pkg/coverage/parser/goparser"
 	"github.com/cvgw/gocheckcov/pkg/coverage/parser/goparser/functions"
+	"github.com/cvgw/gocheckcov/pkg/coverage/parser/goparser/statements"
 	. "github.com/onsi/gomega"
 	"golang.org/x/tools/cover"
 )
@@ -171,7 +172,7 @@ meow
 			}
 			fset := token.NewFileSet()
 
-			res, err := goparser.NodeFromFilePath("", srcFilePath, fset)
+			res, err := goparser.NodeFromFilePath(srcFilePath, fset)
 
 			if tc.expectErr {
 				g.Expect(err).ToNot(BeNil())
@@ -258,6 +259,184 @@ func Test_Parser_RecordFunctionCoverage(t *testing.T) {
 				},
 			},
 		},
+		func() testcase {
+			stmtProfile := &cover.Profile{
+				Blocks: []cover.ProfileBlock{
+					{StartLine: 3, StartCol: 26, EndLine: 4, EndCol: 10, Count: 3, NumStmt: 1},
+					{StartLine: 4, StartCol: 10, EndLine: 6, EndCol: 3, Count: 0, NumStmt: 1},
+					{StartLine: 7, StartCol: 2, EndLine: 7, EndCol: 14, Count: 3, NumStmt: 1},
+				},
+			}
+			fn := functions.Function{
+				StartLine: 3, StartCol: 1, EndLine: 8, EndCol: 2,
+				Statements: []statements.Statement{
+					{StartLine: 4, StartCol: 2, EndLine: 6, EndCol: 3},
+					{StartLine: 5, StartCol: 3, EndLine: 5, EndCol: 14},
+					{StartLine: 7, StartCol: 2, EndLine: 7, EndCol: 14},
+				},
+			}
+			recorded := fn
+			recorded.Statements = []statements.Statement{
+				{StartLine: 4, StartCol: 2, EndLine: 6, EndCol: 3, ExecutedCount: 3},
+				{StartLine: 5, StartCol: 3, EndLine: 5, EndCol: 14},
+				{StartLine: 7, StartCol: 2, EndLine: 7, EndCol: 14, ExecutedCount: 3},
+			}
+
+			return testcase{
+				description: "statements record the count of the block they start in",
+				profile:     stmtProfile,
+				functions:   []functions.Function{fn},
+				expectCoverages: []FunctionCoverage{
+					{
+						StatementCount:   3,
+						CoveredCount:     2,
+						LineCount:        3,
+						CoveredLineCount: 2,
+						Function:         recorded,
+						Profile:          stmtProfile,
+					},
+				},
+			}
+		}(),
+		func() testcase {
+			branchProfile := &cover.Profile{
+				Blocks: []cover.ProfileBlock{
+					{StartLine: 4, StartCol: 2, EndLine: 4, EndCol: 12, Count: 1, NumStmt: 1},
+					{StartLine: 5, StartCol: 3, EndLine: 5, EndCol: 14, Count: 1, NumStmt: 1},
+					{StartLine: 7, StartCol: 3, EndLine: 7, EndCol: 15, Count: 0, NumStmt: 1},
+				},
+			}
+			fn := functions.Function{
+				StartLine: 3, StartCol: 1, EndLine: 8, EndCol: 2,
+				Branches: []statements.Branch{
+					{Kind: "if", StartLine: 4, StartCol: 2, Arms: []statements.Arm{{Line: 5, Col: 3}, {Line: 7, Col: 3}}},
+				},
+			}
+			recorded := fn
+			recorded.Branches = []statements.Branch{
+				{Kind: "if", StartLine: 4, StartCol: 2, Arms: []statements.Arm{
+					{Line: 5, Col: 3, ExecutedCount: 1},
+					{Line: 7, Col: 3},
+				}},
+			}
+
+			return testcase{
+				description: "branch arms record the count of the block they start in",
+				profile:     branchProfile,
+				functions:   []functions.Function{fn},
+				expectCoverages: []FunctionCoverage{
+					{
+						StatementCount:     3,
+						CoveredCount:       2,
+						BranchCount:        2,
+						CoveredBranchCount: 1,
+						Function:           recorded,
+						Profile:            branchProfile,
+					},
+				},
+			}
+		}(),
+		func() testcase {
+			implicitProfile := &cover.Profile{
+				Blocks: []cover.ProfileBlock{
+					{StartLine: 3, StartCol: 21, EndLine: 4, EndCol: 11, Count: 4, NumStmt: 1},
+					{StartLine: 4, StartCol: 11, EndLine: 6, EndCol: 3, Count: 1, NumStmt: 1},
+					{StartLine: 7, StartCol: 2, EndLine: 7, EndCol: 10, Count: 3, NumStmt: 1},
+				},
+			}
+			fn := functions.Function{
+				StartLine: 3, StartCol: 1, EndLine: 8, EndCol: 2,
+				Branches: []statements.Branch{
+					{Kind: "if", StartLine: 4, StartCol: 2, Arms: []statements.Arm{
+						{Line: 5, Col: 3},
+						{Line: 4, Col: 2, Implicit: true},
+					}},
+				},
+			}
+			recorded := fn
+			recorded.Branches = []statements.Branch{
+				{Kind: "if", StartLine: 4, StartCol: 2, Arms: []statements.Arm{
+					{Line: 5, Col: 3, ExecutedCount: 1},
+					{Line: 4, Col: 2, ExecutedCount: 3, Implicit: true},
+				}},
+			}
+
+			return testcase{
+				description: "implicit arms record the runs of their branch the other arms didn't take",
+				profile:     implicitProfile,
+				functions:   []functions.Function{fn},
+				expectCoverages: []FunctionCoverage{
+					{
+						StatementCount:     3,
+						CoveredCount:       3,
+						BranchCount:        2,
+						CoveredBranchCount: 2,
+						Function:           recorded,
+						Profile:            implicitProfile,
+					},
+				},
+			}
+		}(),
+		func() testcase {
+			ignoreProfile := &cover.Profile{
+				Blocks: []cover.ProfileBlock{
+					{StartLine: 3, StartCol: 26, EndLine: 4, EndCol: 10, Count: 1, NumStmt: 1},
+					{StartLine: 5, StartCol: 3, EndLine: 6, EndCol: 14, Count: 0, NumStmt: 2},
+				},
+			}
+			fn := functions.Function{
+				StartLine: 3, StartCol: 1, EndLine: 8, EndCol: 2,
+				Statements: []statements.Statement{
+					{StartLine: 4, StartCol: 2, EndLine: 7, EndCol: 3},
+					{StartLine: 5, StartCol: 3, EndLine: 5, EndCol: 14},
+					{StartLine: 6, StartCol: 3, EndLine: 6, EndCol: 14, Ignored: true},
+				},
+			}
+
+			recorded := fn
+			recorded.Statements = append([]statements.Statement{}, fn.Statements...)
+			recorded.Statements[0].ExecutedCount = 1
+
+			return testcase{
+				description: "ignored statements are not counted",
+				profile:     ignoreProfile,
+				functions:   []functions.Function{fn},
+				expectCoverages: []FunctionCoverage{
+					{
+						StatementCount:   2,
+						CoveredCount:     1,
+						LineCount:        2,
+						CoveredLineCount: 1,
+						Function:         recorded,
+						Profile:          ignoreProfile,
+					},
+				},
+			}
+		}(),
+		func() testcase {
+			closureProfile := &cover.Profile{
+				Blocks: []cover.ProfileBlock{
+					{StartLine: 3, StartCol: 20, EndLine: 4, EndCol: 15, Count: 1, NumStmt: 1},
+					{StartLine: 4, StartCol: 16, EndLine: 6, EndCol: 3, Count: 0, NumStmt: 2},
+					{StartLine: 7, StartCol: 2, EndLine: 7, EndCol: 12, Count: 1, NumStmt: 1},
+				},
+			}
+			outer := functions.Function{
+				Name: "Outer", StartLine: 3, StartCol: 1, EndLine: 8, EndCol: 2,
+				Closures: []functions.Range{{StartLine: 4, StartCol: 15, EndLine: 6, EndCol: 3}},
+			}
+			closure := functions.Function{Name: "Outer.func1", StartLine: 4, StartCol: 15, EndLine: 6, EndCol: 3}
+
+			return testcase{
+				description: "statements of closures are not counted by the enclosing function",
+				profile:     closureProfile,
+				functions:   []functions.Function{outer, closure},
+				expectCoverages: []FunctionCoverage{
+					{Name: "Outer", StatementCount: 2, CoveredCount: 2, Function: outer, Profile: closureProfile},
+					{Name: "Outer.func1", StatementCount: 2, Function: closure, Profile: closureProfile},
+				},
+			}
+		}(),
 	}
 
 	for i := range testCases {
@@ -272,3 +451,48 @@ func Test_Parser_RecordFunctionCoverage(t *testing.T) {
 		})
 	}
 }
+
+func Test_FunctionCoverage_CRAP(t *testing.T) {
+	type testcase struct {
+		description string
+		coverage    FunctionCoverage
+		expectCRAP  float64
+	}
+
+	testCases := []testcase{
+		{
+			description: "fully covered",
+			coverage: FunctionCoverage{
+				StatementCount: 10, CoveredCount: 10, Function: functions.Function{Complexity: 5},
+			},
+			expectCRAP: 5,
+		},
+		{
+			description: "not covered",
+			coverage: FunctionCoverage{
+				StatementCount: 10, Function: functions.Function{Complexity: 5},
+			},
+			expectCRAP: 30,
+		},
+		{
+			description: "half covered",
+			coverage: FunctionCoverage{
+				StatementCount: 10, CoveredCount: 5, Function: functions.Function{Complexity: 4},
+			},
+			expectCRAP: 6,
+		},
+		{
+			description: "no statements",
+			coverage:    FunctionCoverage{Function: functions.Function{Complexity: 1}},
+			expectCRAP:  2,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			g.Expect(tc.coverage.CRAP()).To(Equal(tc.expectCRAP))
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright © 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package profile
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/cvgw/gocheckcov/pkg/coverage/parser/goparser/functions"
+	"golang.org/x/tools/cover"
+)
+
+// StaleProfileError is returned when the blocks of a profile don't fit the
+// current source of a file, which happens when the file changed after the
+// profile was generated.
+type StaleProfileError struct {
+	FilePath string
+	Reason   string
+}
+
+func (e *StaleProfileError) Error() string {
+	return fmt.Sprintf("profile out of date for file %v: %v", e.FilePath, e.Reason)
+}
+
+// Validate checks that every block of the profile is inside of src and that no
+// block crosses the boundary of one of functions.
+func (p Parser) Validate(src []byte, functions []functions.Function) error {
+	if p.Profile == nil {
+		return nil
+	}
+
+	lines := bytes.Split(src, []byte("\n"))
+
+	for _, block := range p.Profile.Blocks {
+		if reason, ok := blockInSrc(block, lines); !ok {
+			return &StaleProfileError{FilePath: p.FilePath, Reason: reason}
+		}
+	}
+
+	idx := newBlockIndex(p.Profile.Blocks)
+
+	for _, function := range functions {
+		var crossing []cover.ProfileBlock
+
+		idx.overlapping(functionStart(function), functionEnd(function), func(block cover.ProfileBlock) {
+			if crossesFunction(block, function) {
+				crossing = append(crossing, block)
+			}
+		})
+
+		if len(crossing) > 0 {
+			return &StaleProfileError{
+				FilePath: p.FilePath,
+				Reason: fmt.Sprintf(
+					"block %v crosses the boundary of function %v at %v.%v,%v.%v",
+					blockString(crossing[0]),
+					function.Name,
+					function.StartLine, function.StartCol, function.EndLine, function.EndCol,
+				),
+			}
+		}
+	}
+
+	return nil
+}
+
+func blockInSrc(block cover.ProfileBlock, lines [][]byte) (string, bool) {
+	if block.StartLine < 1 || block.EndLine > len(lines) || block.StartLine > block.EndLine {
+		return fmt.Sprintf("block %v is outside of the file's %v lines", blockString(block), len(lines)), false
+	}
+
+	// columns are 1 based and may point just past the last byte of a line
+	if block.StartCol < 1 || block.StartCol > len(lines[block.StartLine-1])+1 {
+		return fmt.Sprintf("block %v starts past the end of line %v", blockString(block), block.StartLine), false
+	}
+
+	if block.EndCol < 1 || block.EndCol > len(lines[block.EndLine-1])+1 {
+		return fmt.Sprintf("block %v ends past the end of line %v", blockString(block), block.EndLine), false
+	}
+
+	return "", true
+}
+
+// crossesFunction reports whether block partially overlaps function. Blocks
+// are generated per function so they are either fully inside of a function or
+// fully outside of it.
+func crossesFunction(block cover.ProfileBlock, function functions.Function) bool {
+	outside := !functionStart(function).before(blockEnd(block)) || !blockStart(block).before(functionEnd(function))
+	inside := !blockStart(block).before(functionStart(function)) && !functionEnd(function).before(blockEnd(block))
+
+	return !outside && !inside
+}
+
+func blockString(block cover.ProfileBlock) string {
+	return fmt.Sprintf("%v.%v,%v.%v", block.StartLine, block.StartCol, block.EndLine, block.EndCol)
+}
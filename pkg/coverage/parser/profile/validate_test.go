@@ -0,0 +1,90 @@
+// Copyright © 2019 Cole Giovannoni Wippern
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package profile
+
+import (
+	"testing"
+
+	"github.com/cvgw/gocheckcov/pkg/coverage/parser/goparser/functions"
+	. "github.com/onsi/gomega"
+	"golang.org/x/tools/cover"
+)
+
+func Test_Parser_Validate(t *testing.T) {
+	src := []byte(`package foo
+
+func Meow(x, y int) bool {
+	if x > y {
+		return true
+	}
+	return false
+}
+`)
+
+	meow := functions.Function{Name: "Meow", StartLine: 3, StartCol: 1, EndLine: 8, EndCol: 2}
+
+	type testcase struct {
+		description string
+		blocks      []cover.ProfileBlock
+		expectErr   bool
+	}
+
+	testCases := []testcase{
+		{
+			description: "blocks match source",
+			blocks: []cover.ProfileBlock{
+				{StartLine: 3, StartCol: 26, EndLine: 4, EndCol: 10},
+				{StartLine: 4, StartCol: 10, EndLine: 6, EndCol: 3},
+				{StartLine: 7, StartCol: 2, EndLine: 7, EndCol: 14},
+			},
+		},
+		{
+			description: "block past the last line",
+			blocks: []cover.ProfileBlock{
+				{StartLine: 7, StartCol: 2, EndLine: 12, EndCol: 14},
+			},
+			expectErr: true,
+		},
+		{
+			description: "block past the end of a line",
+			blocks: []cover.ProfileBlock{
+				{StartLine: 7, StartCol: 2, EndLine: 7, EndCol: 40},
+			},
+			expectErr: true,
+		},
+		{
+			description: "block crosses function boundary",
+			blocks: []cover.ProfileBlock{
+				{StartLine: 1, StartCol: 2, EndLine: 4, EndCol: 3},
+			},
+			expectErr: true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			p := Parser{FilePath: "foo/foo.go", Profile: &cover.Profile{Blocks: tc.blocks}}
+			err := p.Validate(src, []functions.Function{meow})
+			if tc.expectErr {
+				g.Expect(err).To(BeAssignableToTypeOf(&StaleProfileError{}))
+				g.Expect(err.Error()).To(ContainSubstring("profile out of date for file foo/foo.go"))
+			} else {
+				g.Expect(err).To(BeNil())
+			}
+		})
+	}
+}
@@ -18,24 +18,28 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
-	"go/build"
 	"io/ioutil"
 	"math"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/fatih/color"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/tools/cover"
+	"gopkg.in/yaml.v2"
 
 	"github.com/cvgw/gocheckcov/pkg/coverage/analyzer"
 	"github.com/cvgw/gocheckcov/pkg/coverage/config"
+	"github.com/cvgw/gocheckcov/pkg/coverage/files"
+	"github.com/cvgw/gocheckcov/pkg/coverage/parser/goparser/functions"
+	"github.com/cvgw/gocheckcov/pkg/coverage/parser/goparser/statements"
 	"github.com/cvgw/gocheckcov/pkg/coverage/parser/profile"
-	"gopkg.in/yaml.v2"
 )
 
 func NewCliTabLogger() *CliLogger {
@@ -81,9 +85,26 @@ type Logger interface {
 type Verifier struct {
 	Out            Logger
 	MinCov         float64
-	GoSrcPath      string
+	MinBranchCov   float64
+	MinLineCov     float64
+	MinFunctionCov float64
+	MaxCRAP        float64
+	MinAPICov      float64
+	Explain        bool
+	Resolver       files.PathResolver
 	PrintSrc       bool
 	PrintFunctions bool
+	PrintUncovered bool
+	PrintFiles     bool
+	// ProfileOnly is set when coverage was computed from the profile alone and
+	// each FunctionCoverage stands for a whole file
+	ProfileOnly bool
+	// Now is the date waivers are checked against, today if it is zero
+	Now               time.Time
+	WaiverWarningDays int
+
+	// cfg is the config of the package being verified, for its waivers
+	cfg *config.ConfigFile
 }
 
 func (v Verifier) ReportCoverage(
@@ -91,62 +112,295 @@ func (v Verifier) ReportCoverage(
 	printFunctions bool,
 	configFile []byte,
 ) (map[string]float64, error) {
-	pkgToCoverage := make(map[string]float64)
-	pc := analyzer.NewPackageCoverages(packageToFunctions)
+	var configs map[string]*config.ConfigFile
 
+	if len(configFile) != 0 {
+		c, err := config.ParseConfigFile(configFile)
+		if err != nil {
+			err = errors.Wrap(err, "could not unmarshal yaml for config file")
+			log.Debug(err)
+
+			return nil, err
+		}
+
+		configs = make(map[string]*config.ConfigFile, len(packageToFunctions))
+		for pkg := range packageToFunctions {
+			configs[pkg] = &c
+		}
+	}
+
+	if err := v.ReportPackageCoverage(packageToFunctions, configs); err != nil {
+		return nil, err
+	}
+
+	return make(map[string]float64), nil
+}
+
+// ReportPackageCoverage checks each package against its own config from
+// configs. Packages without a config are checked against the thresholds of
+// the verifier.
+func (v Verifier) ReportPackageCoverage(
+	packageToFunctions map[string][]profile.FunctionCoverage,
+	configs map[string]*config.ConfigFile,
+) error {
 	fail := false
-	keys := make([]string, 0, len(packageToFunctions))
+	keys := SortedPackages(packageToFunctions)
 
-	for pkg := range packageToFunctions {
-		keys = append(keys, pkg)
+	rest := make(map[string][]profile.FunctionCoverage, len(packageToFunctions))
+	ruleFiles := make(map[string][]profile.FunctionCoverage)
+
+	for pkg, functions := range packageToFunctions {
+		rest[pkg], ruleFiles[pkg] = SplitRuleFiles(functions, configs[pkg])
 	}
 
-	sort.Strings(keys)
+	pc := analyzer.NewPackageCoverages(rest)
 
 	for _, pkg := range keys {
-		var cfgPkg config.ConfigPackage
+		cfg := configs[pkg]
+		cfgPkg, rule := v.packageConfig(pkg, cfg)
 
-		if len(configFile) != 0 {
-			cfg := config.ConfigFile{}
-			if err := yaml.Unmarshal(configFile, &cfg); err != nil {
-				err = errors.Wrap(err, "could not unmarshal yaml for config file %v")
-				log.Debug(err)
+		if v.Explain {
+			v.Out.Printf("pkg  %v\tconfigured by %v\n", pkg, rule)
+		}
+
+		if v.ProfileOnly {
+			cfgPkg = v.skipSourceThresholds(cfgPkg)
+		}
 
-				return nil, err
+		pv := v
+		pv.cfg = cfg
+
+		ok, err := pv.VerifyCoverage(cfgPkg, pc)
+		if err != nil {
+			log.Debug(err)
+			return err
+		}
+
+		if !ok {
+			fail = true
+		}
+
+		if !pv.verifyFiles(pkg, ruleFiles[pkg], cfg) {
+			fail = true
+		}
+	}
+
+	v.warnWaivers(keys, configs)
+
+	if fail {
+		return fmt.Errorf("packages failed to meet minimum coverage")
+	}
+
+	return nil
+}
+
+// PrintConfigs prints the effective config of each package in configs and the
+// config files it was merged from
+func (v Verifier) PrintConfigs(packages []string, configs map[string]*config.ConfigFile) error {
+	for _, pkg := range packages {
+		cfg := configs[pkg]
+		cfgPkg, rule := v.packageConfig(pkg, cfg)
+
+		v.Out.Printf("pkg  %v\tconfigured by %v\n", pkg, rule)
+
+		if cfg != nil {
+			for _, source := range cfg.Sources() {
+				v.Out.Printf("  from %v\n", v.displayPath(source))
 			}
+		}
+
+		effective := struct {
+			config.ConfigPackage `yaml:",inline"`
+			IncludeGenerated     bool                    `yaml:"include_generated"`
+			Files                []config.ConfigFileRule `yaml:"files,omitempty"`
+			Waivers              []config.Waiver         `yaml:"waivers,omitempty"`
+		}{ConfigPackage: cfgPkg}
 
-			var ok bool
-			cfgPkg, ok = cfg.GetPackage(pkg)
+		if cfg != nil {
+			effective.IncludeGenerated = cfg.IncludeGenerated
+			effective.Files = cfg.Files
 
-			if !ok {
-				cfgPkg = config.ConfigPackage{
-					Name:                  pkg,
-					MinCoveragePercentage: cfg.MinCoveragePercentage,
+			for _, w := range cfg.Waivers {
+				if w.MatchesPackage(pkg) {
+					effective.Waivers = append(effective.Waivers, w)
 				}
 			}
-		} else {
-			cfgPkg = config.ConfigPackage{
-				Name:                  pkg,
-				MinCoveragePercentage: v.MinCov,
-			}
 		}
 
-		ok, err := v.VerifyCoverage(cfgPkg, pc)
+		effective.Name = ""
+
+		out, err := yaml.Marshal(effective)
 		if err != nil {
-			log.Debug(err)
-			return nil, err
+			return err
 		}
 
-		if !ok {
-			fail = true
+		for _, line := range strings.Split(strings.TrimSuffix(string(out), "\n"), "\n") {
+			if line == `name: ""` {
+				continue
+			}
+
+			v.Out.Printf("  %v\n", line)
 		}
+
+		v.Out.Printf("\n")
 	}
 
-	if fail {
-		return nil, fmt.Errorf("packages failed to meet minimum coverage")
+	return nil
+}
+
+// SortedPackages returns the packages of packageToFunctions sorted by name
+func SortedPackages(packageToFunctions map[string][]profile.FunctionCoverage) []string {
+	keys := make([]string, 0, len(packageToFunctions))
+
+	for pkg := range packageToFunctions {
+		keys = append(keys, pkg)
 	}
 
-	return pkgToCoverage, nil
+	sort.Strings(keys)
+
+	return keys
+}
+
+// SplitRuleFiles returns the functions which count towards the coverage of
+// their package and the functions of files matching one of the files rules of
+// cfg, which are checked on their own as well. Files of rules with
+// exclude_from_package only appear in the second list.
+func SplitRuleFiles(
+	functions []profile.FunctionCoverage,
+	cfg *config.ConfigFile,
+) ([]profile.FunctionCoverage, []profile.FunctionCoverage) {
+	if cfg == nil || len(cfg.Files) == 0 {
+		return functions, nil
+	}
+
+	rest := make([]profile.FunctionCoverage, 0, len(functions))
+	ruleFiles := make([]profile.FunctionCoverage, 0)
+
+	for _, function := range functions {
+		rule, _, ok := cfg.MatchFile(function.Function.SrcPath)
+		if ok {
+			ruleFiles = append(ruleFiles, function)
+		}
+
+		if !ok || !rule.ExcludeFromPackage {
+			rest = append(rest, function)
+		}
+	}
+
+	return rest, ruleFiles
+}
+
+// verifyFiles checks the files of functions in pkg against the files rules of
+// cfg they match and reports whether all of them meet their minimum or are
+// waived
+func (v Verifier) verifyFiles(pkg string, functions []profile.FunctionCoverage, cfg *config.ConfigFile) bool {
+	if len(functions) == 0 || cfg == nil {
+		return true
+	}
+
+	ok := true
+	printed := false
+
+	for _, file := range analyzer.FileCoverages(functions) {
+		rule, i, _ := cfg.MatchFile(file.FilePath)
+		printed = true
+
+		if v.Explain {
+			v.Out.Printf("file %v\tconfigured by %v\n", file.FilePath, cfg.FileRule(i))
+		}
+
+		args := []interface{}{
+			file.FilePath,
+			file.CoveragePercent,
+			rule.MinCoveragePercentage,
+			file.ExecutedCount,
+			file.StatementCount,
+		}
+		format := "file %v\tcoverage %v%% \tminimum %v%% \tstatements\t%v/%v"
+
+		if rule.MinCoveragePercentage <= file.CoveragePercent {
+			v.Out.Printf(format+"\n", args...)
+			continue
+		}
+
+		if v.printFailure(pkg, "", format, args...) {
+			ok = false
+		}
+	}
+
+	if printed {
+		v.Out.Printf("\n")
+	}
+
+	return ok
+}
+
+// skipSourceThresholds returns pkg without the thresholds which need the
+// source to be checked and prints each one that was set. Without source there
+// are no branches, exported functions, complexity or lines statements start
+// on, and each file stands in for a function.
+func (v Verifier) skipSourceThresholds(pkg config.ConfigPackage) config.ConfigPackage {
+	skip := func(key string, value interface{}) {
+		v.Out.Printf("pkg  %v\tskipped %v %v, not available with --profile-only\n", pkg.Name, key, value)
+	}
+
+	if pkg.MinBranchCoveragePercentage > 0 {
+		skip("min_branch_coverage_percentage", pkg.MinBranchCoveragePercentage)
+		pkg.MinBranchCoveragePercentage = 0
+	}
+
+	if pkg.MinAPICoveragePercentage > 0 {
+		skip("min_api_coverage_percentage", pkg.MinAPICoveragePercentage)
+		pkg.MinAPICoveragePercentage = 0
+	}
+
+	if pkg.MinLineCoveragePercentage > 0 {
+		skip("min_line_coverage_percentage", pkg.MinLineCoveragePercentage)
+		pkg.MinLineCoveragePercentage = 0
+	}
+
+	if pkg.MinFunctionCoveragePercentage > 0 {
+		skip("min_function_coverage_percentage", pkg.MinFunctionCoveragePercentage)
+		pkg.MinFunctionCoveragePercentage = 0
+	}
+
+	if pkg.MaxCRAPScore > 0 {
+		skip("max_crap_score", pkg.MaxCRAPScore)
+		pkg.MaxCRAPScore = 0
+	}
+
+	if len(pkg.Functions) > 0 {
+		skip("functions rules", len(pkg.Functions))
+		pkg.Functions = nil
+	}
+
+	return pkg
+}
+
+// packageConfig returns the thresholds for pkg and a description of where they
+// came from. Without a config file the thresholds of the verifier are used.
+func (v Verifier) packageConfig(pkg string, cfg *config.ConfigFile) (config.ConfigPackage, string) {
+	if cfg == nil {
+		return config.ConfigPackage{
+			Name:                          pkg,
+			MinCoveragePercentage:         v.MinCov,
+			MinBranchCoveragePercentage:   v.MinBranchCov,
+			MinLineCoveragePercentage:     v.MinLineCov,
+			MinFunctionCoveragePercentage: v.MinFunctionCov,
+			MaxCRAPScore:                  v.MaxCRAP,
+			MinAPICoveragePercentage:      v.MinAPICov,
+		}, "command line flags"
+	}
+
+	cfgPkg, i, ok := cfg.MatchPackage(pkg)
+	if !ok {
+		return cfg.GlobalPackage(pkg), "global config"
+	}
+
+	rule := cfg.PackageRule(i)
+	cfgPkg.Name = pkg
+
+	return cfgPkg, rule
 }
 
 func (v Verifier) VerifyCoverage(pkg config.ConfigPackage, pc *analyzer.PackageCoverages) (bool, error) {
@@ -166,50 +420,627 @@ func (v Verifier) VerifyCoverage(pkg config.ConfigPackage, pc *analyzer.PackageC
 		return false, err
 	}
 
-	v.Out.Printf(
-		"pkg  %v\tcoverage %v%% \tminimum %v%% \tstatements\t%v/%v\n",
-		pkg.Name,
-		cov.CoveragePercent,
-		pkg.MinCoveragePercentage,
-		cov.ExecutedCount,
-		cov.StatementCount,
-	)
+	statements := metric{"statement", "statements", cov.CoveragePercent, pkg.MinCoveragePercentage,
+		cov.ExecutedCount, cov.StatementCount}
+	metrics := []metric{
+		{"line", "lines", cov.LineCoveragePercent, pkg.MinLineCoveragePercentage,
+			cov.CoveredLineCount, cov.LineCount},
+		{"function", "functions", cov.FunctionCoveragePercent, pkg.MinFunctionCoveragePercentage,
+			cov.CoveredFunctionCount, cov.FunctionCount},
+		{"branch", "branches", cov.BranchCoveragePercent, pkg.MinBranchCoveragePercentage,
+			cov.CoveredBranchCount, cov.BranchCount},
+		{"API", "statements", cov.APICoveragePercent, pkg.MinAPICoveragePercentage,
+			cov.APIExecutedCount, cov.APIStatementCount},
+	}
+
+	if v.ProfileOnly {
+		// coverage from the profile alone has no source to count these on
+		metrics = nil
+	}
+
+	v.printMetrics(pkg.Name, statements, metrics)
+
+	ok = v.verifyMetrics(pkg.Name, append([]metric{statements}, metrics...))
+
+	if !v.verifyStatementCounts(pkg, cov.ExecutedCount, cov.StatementCount) {
+		ok = false
+	}
+
+	if err := v.printReports(cov.Functions, cov.Files); err != nil {
+		return false, err
+	}
+
+	// function rules are checked even if the package already failed so every
+	// failing function is reported
+	if !v.verifyCRAP(pkg, cov.Functions) {
+		ok = false
+	}
+
+	if !v.verifyFunctions(pkg, cov.Functions) {
+		ok = false
+	}
+
+	return ok, nil
+}
+
+// printReports prints the exclusions of functions and the reports asked for
+func (v Verifier) printReports(functions []profile.FunctionCoverage, files []analyzer.FileCoverage) error {
+	v.PrintExclusions(functions)
+
+	if v.PrintFiles {
+		v.PrintFileReport(files)
+	}
 
 	if v.PrintFunctions {
-		if err := v.PrintFunctionReport(cov.Functions); err != nil {
-			return false, err
+		if err := v.PrintFunctionReport(functions); err != nil {
+			return err
 		}
 	}
 
-	if pkg.MinCoveragePercentage > cov.CoveragePercent {
-		return false, nil
+	if v.PrintUncovered {
+		v.PrintUncoveredReport(functions)
 	}
 
-	return true, nil
+	return nil
 }
 
-func (v Verifier) PrintFunctionReport(functions []profile.FunctionCoverage) error {
+// metric is one kind of coverage of a package and its minimum
+type metric struct {
+	name    string
+	unit    string
+	percent float64
+	min     float64
+	covered int64
+	total   int64
+}
+
+// printMetrics prints the statement coverage of pkg followed by each of
+// metrics which has a minimum
+func (v Verifier) printMetrics(pkg string, statements metric, metrics []metric) {
+	line := fmt.Sprintf(
+		"pkg  %v\tcoverage %v%% \tminimum %v%% \t%v\t%v/%v",
+		pkg,
+		statements.percent,
+		statements.min,
+		statements.unit,
+		statements.covered,
+		statements.total,
+	)
+
+	for _, m := range metrics {
+		if m.min <= 0 {
+			continue
+		}
+
+		line += fmt.Sprintf(
+			"\t%v coverage %v%% \tminimum %v%% \t%v\t%v/%v",
+			m.name, m.percent, m.min, m.unit, m.covered, m.total,
+		)
+	}
+
+	v.Out.Printf("%v\n", line)
+}
+
+// verifyMetrics prints each of metrics below its minimum and reports whether
+// there were none that count
+func (v Verifier) verifyMetrics(pkg string, metrics []metric) bool {
+	ok := true
+
+	for _, m := range metrics {
+		if m.min <= m.percent {
+			continue
+		}
+
+		if v.printFailure(pkg, "", "pkg  %v\tfailed %v coverage %v%%, minimum %v%%", pkg, m.name, m.percent, m.min) {
+			ok = false
+		}
+	}
+
+	return ok
+}
+
+// waiver returns the waiver in the config being verified which applies to
+// function of pkg, or to pkg itself if function is empty
+func (v Verifier) waiver(pkg, function string) (config.Waiver, bool) {
+	if v.cfg == nil {
+		return config.Waiver{}, false
+	}
+
+	w, _, ok := v.cfg.MatchWaiver(pkg, function, v.now())
+
+	return w, ok
+}
+
+func (v Verifier) now() time.Time {
+	if v.Now.IsZero() {
+		return time.Now()
+	}
+
+	return v.Now
+}
+
+// warnWaivers prints a warning for each waiver in configs which has expired
+// or expires within WaiverWarningDays
+func (v Verifier) warnWaivers(packages []string, configs map[string]*config.ConfigFile) {
+	seen := make(map[string]bool)
+
+	for _, pkg := range packages {
+		cfg := configs[pkg]
+		if cfg == nil {
+			continue
+		}
+
+		for i, w := range cfg.Waivers {
+			rule := cfg.WaiverRule(i)
+			if seen[rule] {
+				continue
+			}
+
+			seen[rule] = true
+
+			switch days := w.DaysLeft(v.now()); {
+			case days < 0:
+				v.Out.Printf("warning: %v owned by %v expired on %v and no longer applies\n", rule, w.Owner, w.Expires)
+			case days == 0:
+				v.Out.Printf("warning: %v owned by %v expires today\n", rule, w.Owner)
+			case days <= v.WaiverWarningDays:
+				v.Out.Printf("warning: %v owned by %v expires on %v, in %v days\n", rule, w.Owner, w.Expires, days)
+			}
+		}
+	}
+}
+
+// verifyStatementCounts checks the number of covered and uncovered statements
+// against the limits of pkg. When any statement based check fails it prints
+// how many more statements need to be covered to pass all of them.
+func (v Verifier) verifyStatementCounts(pkg config.ConfigPackage, executed, total int64) bool {
+	ok := true
+	uncovered := total - executed
+
+	if pkg.MaxUncoveredStatements != nil && uncovered > *pkg.MaxUncoveredStatements &&
+		v.printFailure(
+			pkg.Name,
+			"",
+			"pkg  %v\tfailed uncovered statements %v, maximum %v",
+			pkg.Name,
+			uncovered,
+			*pkg.MaxUncoveredStatements,
+		) {
+		ok = false
+	}
+
+	if executed < pkg.MinCoveredStatements &&
+		v.printFailure(
+			pkg.Name,
+			"",
+			"pkg  %v\tfailed covered statements %v, minimum %v",
+			pkg.Name,
+			executed,
+			pkg.MinCoveredStatements,
+		) {
+		ok = false
+	}
+
+	// the statement percentage is checked with the other metrics, there is
+	// nothing to act on when its failures are waived
+	if more := statementsToCover(executed, total, pkg); more > 0 {
+		if _, waived := v.waiver(pkg.Name, ""); !waived {
+			v.Out.Printf("pkg  %v\tcover %v more statements to pass\n", pkg.Name, more)
+		}
+	}
+
+	return ok
+}
+
+// statementsToCover returns the fewest statements which need to be covered on
+// top of executed for the statement thresholds of pkg to pass. It can't be
+// more than the uncovered statements, or the minimum of covered statements
+// for a package which doesn't have enough statements.
+func statementsToCover(executed, total int64, pkg config.ConfigPackage) int64 {
+	var more int64
+
+	if pkg.MinCoveragePercentage > 0 {
+		// start just below the estimate so float rounding can't overshoot,
+		// then count up to the smallest number the percentage check accepts
+		need := int64(pkg.MinCoveragePercentage*float64(total)/100) - 1 - executed
+		if need < 0 {
+			need = 0
+		}
+
+		for executed+need < total && analyzer.Percent(executed+need, total) < pkg.MinCoveragePercentage {
+			need++
+		}
+
+		more = need
+	}
+
+	if pkg.MaxUncoveredStatements != nil {
+		if need := total - executed - *pkg.MaxUncoveredStatements; need > more {
+			more = need
+		}
+	}
+
+	if need := pkg.MinCoveredStatements - executed; need > more {
+		more = need
+	}
+
+	return more
+}
+
+// verifyFunctions prints each function below the minimum coverage of the
+// function rule of pkg it matches and reports whether there were none
+func (v Verifier) verifyFunctions(pkg config.ConfigPackage, functions []profile.FunctionCoverage) bool {
+	if len(pkg.Functions) == 0 {
+		return true
+	}
+
+	ok := true
+	printed := false
+
 	for _, function := range functions {
-		if function.StatementCount == 0 {
+		rule, _, matched := pkg.MatchFunction(function.Name)
+		if !matched {
+			continue
+		}
+
+		percent := functionPercent(function)
+		if rule.MinCoveragePercentage <= percent {
+			continue
+		}
+
+		printed = true
+
+		if v.printFailure(
+			pkg.Name,
+			function.Name,
+			"func %v\tcoverage %v%% \tminimum %v%% \tstatements\t%v/%v",
+			function.Name,
+			percent,
+			rule.MinCoveragePercentage,
+			function.CoveredCount,
+			function.StatementCount,
+		) {
+			ok = false
+		}
+	}
+
+	if printed {
+		v.Out.Printf("\n")
+	}
+
+	return ok
+}
+
+// printFailure prints the row format with args for a failure of function of
+// pkg, or of pkg itself if function is empty, and reports whether it counts.
+// Failures covered by a waiver are marked as waived and don't count.
+func (v Verifier) printFailure(pkg, function, format string, args ...interface{}) bool {
+	if w, ok := v.waiver(pkg, function); ok {
+		v.Out.Printf(format+"\twaived, %v\n", append(args, w)...)
+		return false
+	}
+
+	v.Out.Printf(format+"\n", args...)
+
+	return true
+}
+
+func functionPercent(function profile.FunctionCoverage) float64 {
+	if function.StatementCount == 0 {
+		return 100
+	}
+
+	val := (float64(function.CoveredCount) / float64(function.StatementCount)) * 10000
+
+	return math.Floor(val) / 100
+}
+
+// verifyCRAP prints each function with a CRAP score over the maximum of pkg
+// and reports whether there were none
+func (v Verifier) verifyCRAP(pkg config.ConfigPackage, functions []profile.FunctionCoverage) bool {
+	if pkg.MaxCRAPScore <= 0 {
+		return true
+	}
+
+	ok := true
+	printed := false
+
+	for _, function := range functions {
+		crap := function.CRAP()
+		if crap <= pkg.MaxCRAPScore {
+			continue
+		}
+
+		printed = true
+
+		if v.printFailure(pkg.Name, function.Name, "func %v\tCRAP %v \tmaximum %v", function.Name, crap, pkg.MaxCRAPScore) {
+			ok = false
+		}
+	}
+
+	if printed {
+		v.Out.Printf("\n")
+	}
+
+	return ok
+}
+
+// PrintUncoveredReport prints the line ranges of the statements in functions
+// that were never executed as file:start-end, merging ranges that touch. It
+// also prints each branch with an arm that never ran, along with which of its
+// arms ran and which didn't.
+func (v Verifier) PrintUncoveredReport(functions []profile.FunctionCoverage) {
+	ranges := make(map[string][]lineRange)
+	branches := make(map[string][]statements.Branch)
+	filePaths := make([]string, 0)
+	seen := make(map[string]bool)
+
+	addFile := func(filePath string) {
+		if !seen[filePath] {
+			seen[filePath] = true
+
+			filePaths = append(filePaths, filePath)
+		}
+	}
+
+	for _, function := range functions {
+		filePath := function.Function.SrcPath
+
+		for _, stmt := range function.Function.Statements {
+			if stmt.ExecutedCount > 0 || stmt.Ignored {
+				continue
+			}
+
+			addFile(filePath)
+
+			ranges[filePath] = append(ranges[filePath], lineRange{start: stmt.StartLine, end: stmt.EndLine})
+		}
+
+		for _, branch := range function.Function.Branches {
+			if _, notRun := armLabels(branch); len(notRun) > 0 {
+				addFile(filePath)
+
+				branches[filePath] = append(branches[filePath], branch)
+			}
+		}
+	}
+
+	if len(filePaths) == 0 {
+		return
+	}
+
+	sort.Strings(filePaths)
+
+	for _, filePath := range filePaths {
+		v.printUncoveredFile(filePath, ranges[filePath], branches[filePath])
+	}
+
+	v.Out.Printf("\n")
+}
+
+// printUncoveredFile prints the uncovered line ranges and branches of a file
+func (v Verifier) printUncoveredFile(filePath string, ranges []lineRange, branches []statements.Branch) {
+	for _, r := range mergeLineRanges(ranges) {
+		v.Out.Printf("uncovered %v:%v-%v\n", v.displayPath(filePath), r.start, r.end)
+	}
+
+	sort.SliceStable(branches, func(i, j int) bool {
+		return branches[i].StartLine < branches[j].StartLine
+	})
+
+	for _, branch := range branches {
+		ran, notRun := armLabels(branch)
+		v.Out.Printf(
+			"branch    %v:%v\t%v\tran %v\tnot run %v\n",
+			v.displayPath(filePath),
+			branch.StartLine,
+			branch.Kind,
+			joinLabels(ran),
+			joinLabels(notRun),
+		)
+	}
+}
+
+// armLabels returns the labels of the arms of branch which ran and of those
+// which didn't. Arms are labeled by the line they start on, implicit arms as
+// implicit else or implicit default.
+func armLabels(branch statements.Branch) ([]string, []string) {
+	ran := make([]string, 0, len(branch.Arms))
+	notRun := make([]string, 0)
+
+	for _, arm := range branch.Arms {
+		label := fmt.Sprintf("line %v", arm.Line)
+
+		if arm.Implicit {
+			label = "implicit default"
+			if branch.Kind == "if" {
+				label = "implicit else"
+			}
+		}
+
+		if arm.ExecutedCount > 0 {
+			ran = append(ran, label)
+		} else {
+			notRun = append(notRun, label)
+		}
+	}
+
+	return ran, notRun
+}
+
+func joinLabels(labels []string) string {
+	if len(labels) == 0 {
+		return "none"
+	}
+
+	return strings.Join(labels, ", ")
+}
+
+// PrintExclusions prints each range excluded from coverage by an annotation in
+// coverages along with its reason
+func (v Verifier) PrintExclusions(coverages []profile.FunctionCoverage) {
+	type exclusion struct {
+		filePath string
+		functions.Exclusion
+	}
+
+	seen := make(map[exclusion]bool)
+	exclusions := make([]exclusion, 0)
+
+	for _, function := range coverages {
+		for _, e := range function.Function.Exclusions {
+			// closures share the exclusions of the function they are declared in
+			ex := exclusion{filePath: function.Function.SrcPath, Exclusion: e}
+			if !seen[ex] {
+				seen[ex] = true
+
+				exclusions = append(exclusions, ex)
+			}
+		}
+	}
+
+	if len(exclusions) == 0 {
+		return
+	}
+
+	sort.Slice(exclusions, func(i, j int) bool {
+		if exclusions[i].filePath != exclusions[j].filePath {
+			return exclusions[i].filePath < exclusions[j].filePath
+		}
+
+		return exclusions[i].StartLine < exclusions[j].StartLine
+	})
+
+	for _, e := range exclusions {
+		reason := e.Reason
+		if reason == "" {
+			reason = "no reason given"
+		}
+
+		v.Out.Printf("ignored %v:%v-%v\t%v\n", v.displayPath(e.filePath), e.StartLine, e.EndLine, reason)
+	}
+
+	v.Out.Printf("\n")
+}
+
+type lineRange struct {
+	start int64
+	end   int64
+}
+
+func mergeLineRanges(ranges []lineRange) []lineRange {
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].start < ranges[j].start
+	})
+
+	merged := make([]lineRange, 0, len(ranges))
+
+	for _, r := range ranges {
+		last := len(merged) - 1
+		if last >= 0 && r.start <= merged[last].end+1 {
+			if r.end > merged[last].end {
+				merged[last].end = r.end
+			}
+
 			continue
 		}
 
-		var executedStatementsCount int64
+		merged = append(merged, r)
+	}
+
+	return merged
+}
 
-		executedStatementsCount += function.CoveredCount
+// displayPath returns the path of a file on disk relative to the working
+// directory so it can be opened from the report
+func (v Verifier) displayPath(filePath string) string {
+	diskPath := v.Resolver.FilePath(filePath)
 
-		val := (float64(executedStatementsCount) / float64(function.StatementCount)) * 10000
-		percent := (math.Floor(val) / 100)
+	wd, err := os.Getwd()
+	if err != nil {
+		return diskPath
+	}
+
+	rel, err := filepath.Rel(wd, diskPath)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return diskPath
+	}
+
+	return rel
+}
+
+func (v Verifier) PrintExcludedFiles(files []string) {
+	if len(files) == 0 {
+		return
+	}
+
+	for _, f := range files {
+		v.Out.Printf("file %v\texcluded by build constraints\n", v.Resolver.ImportPath(f))
+	}
+
+	v.Out.Printf("\n")
+}
+
+func (v Verifier) PrintGenerated(generated analyzer.Generated) {
+	if generated.Files == 0 {
+		return
+	}
+
+	v.Out.Printf(
+		"skipped %v generated files with %v statements, set include_generated to check them\n\n",
+		generated.Files,
+		generated.Statements,
+	)
+}
+
+func (v Verifier) PrintProfileErrors(errs []error) {
+	if len(errs) == 0 {
+		return
+	}
+
+	for _, err := range errs {
+		v.Out.Printf("%v\n", err)
+	}
+
+	v.Out.Printf("\n")
+}
+
+func (v Verifier) PrintFileReport(files []analyzer.FileCoverage) {
+	for _, file := range files {
+		v.Out.Printf(
+			"file %v\tcoverage %v%% \t\tstatements\t%v/%v\n",
+			file.FilePath,
+			file.CoveragePercent,
+			file.ExecutedCount,
+			file.StatementCount,
+		)
+	}
+
+	v.Out.Printf("\n")
+}
+
+func (v Verifier) PrintFunctionReport(functions []profile.FunctionCoverage) error {
+	for _, function := range functions {
+		if function.StatementCount == 0 {
+			continue
+		}
+
+		executedStatementsCount := function.CoveredCount
+		percent := functionPercent(function)
 		v.Out.Printf(
-			"func %v\tcoverage %v%% \t\tstatements\t%v/%v\n",
+			"func %v\tcoverage %v%% \t\tstatements\t%v/%v\t\t\tbranches\t%v/%v\tcomplexity %v\tCRAP %v\n",
 			function.Name,
 			percent,
 			executedStatementsCount,
 			function.StatementCount,
+			function.CoveredBranchCount,
+			function.BranchCount,
+			function.Function.Complexity,
+			function.CRAP(),
 		)
 
 		if v.PrintSrc {
-			filePath := filepath.Join(build.Default.GOPATH, "src", function.Function.SrcPath)
+			filePath := v.Resolver.FilePath(function.Function.SrcPath)
 
 			src, err := ioutil.ReadFile(filePath)
 			if err != nil {
@@ -241,7 +1072,9 @@ func (v *Verifier) printSrcWithCoverage(fc profile.FunctionCoverage, src []byte)
 
 	clr := wht
 
-	for i := fc.Function.StartOffset - 1; i < fc.Function.EndOffset+1; i++ {
+	start, end := srcRange(fc.Function, len(src))
+
+	for i := start; i < end; i++ {
 		for _, b := range boundaries {
 			if b.Offset != i {
 				continue
@@ -280,3 +1113,19 @@ func (v *Verifier) printSrcWithCoverage(fc profile.FunctionCoverage, src []byte)
 
 	return nil
 }
+
+// srcRange returns the offsets of the source of f in a file of size bytes,
+// a stale profile or source can put the function outside of it
+func srcRange(f functions.Function, size int) (int, int) {
+	start := f.StartOffset - 1
+	if start < 0 {
+		start = 0
+	}
+
+	end := f.EndOffset + 1
+	if end > size {
+		end = size
+	}
+
+	return start, end
+}
@@ -16,10 +16,13 @@ package reporter
 
 import (
 	"testing"
+	"time"
 
 	"github.com/cvgw/gocheckcov/mocks/coverage/mock_reporter"
 	"github.com/cvgw/gocheckcov/pkg/coverage/analyzer"
 	"github.com/cvgw/gocheckcov/pkg/coverage/config"
+	"github.com/cvgw/gocheckcov/pkg/coverage/parser/goparser/functions"
+	"github.com/cvgw/gocheckcov/pkg/coverage/parser/goparser/statements"
 	"github.com/cvgw/gocheckcov/pkg/coverage/parser/profile"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/gomega"
@@ -51,7 +54,13 @@ func Test_Verifier_VerifyCoverage(t *testing.T) {
 		},
 		"cov is less than pkg min": func(ctrl *gomock.Controller) testcase {
 			mockLogger := mock_reporter.NewMocklogger(ctrl)
-			mockLogger.EXPECT().Printf(gomock.Any(), gomock.Any()).Times(1)
+			gomock.InOrder(
+				mockLogger.EXPECT().Printf(gomock.Any(), gomock.Any()).Times(1),
+				mockLogger.EXPECT().Printf(
+					"pkg  %v\tfailed %v coverage %v%%, minimum %v%%\n", "foo/bar", "statement", float64(10), float64(100),
+				),
+				mockLogger.EXPECT().Printf("pkg  %v\tcover %v more statements to pass\n", "foo/bar", int64(90)),
+			)
 
 			return testcase{
 				verifier: &Verifier{Out: mockLogger},
@@ -69,6 +78,192 @@ func Test_Verifier_VerifyCoverage(t *testing.T) {
 				},
 			}
 		},
+		"every function below its rule is reported": func(ctrl *gomock.Controller) testcase {
+			mockLogger := mock_reporter.NewMocklogger(ctrl)
+			pkgLine := mockLogger.EXPECT().Printf(gomock.Any(), gomock.Any()).Times(1)
+			gomock.InOrder(
+				pkgLine,
+				mockLogger.EXPECT().Printf(
+					"pkg  %v\tfailed %v coverage %v%%, minimum %v%%\n", "foo/bar", "statement", float64(10), float64(70),
+				),
+				mockLogger.EXPECT().Printf("pkg  %v\tcover %v more statements to pass\n", "foo/bar", int64(6)),
+				mockLogger.EXPECT().Printf(
+					"func %v\tcoverage %v%% \tminimum %v%% \tstatements\t%v/%v\n",
+					"ChargeCard", float64(50), float64(100), int64(1), int64(2),
+				),
+				mockLogger.EXPECT().Printf(
+					"func %v\tcoverage %v%% \tminimum %v%% \tstatements\t%v/%v\n",
+					"ChargeRefund", float64(0), float64(100), int64(0), int64(1),
+				),
+				mockLogger.EXPECT().Printf("\n"),
+			)
+
+			return testcase{
+				verifier: &Verifier{Out: mockLogger},
+				coverages: analyzer.NewPackageCoverages(map[string][]profile.FunctionCoverage{
+					"foo/bar": []profile.FunctionCoverage{
+						{Name: "ChargeCard", CoveredCount: 1, StatementCount: 2},
+						{Name: "ChargeRefund", StatementCount: 1},
+						{Name: "helper", StatementCount: 7},
+					},
+				}),
+				pkg: config.ConfigPackage{
+					Name:                  "foo/bar",
+					MinCoveragePercentage: 70,
+					Functions: []config.ConfigFunction{
+						{Name: "Charge*", MinCoveragePercentage: 100},
+					},
+				},
+			}
+		},
+		"each failed metric is reported": func(ctrl *gomock.Controller) testcase {
+			mockLogger := mock_reporter.NewMocklogger(ctrl)
+			gomock.InOrder(
+				mockLogger.EXPECT().Printf(gomock.Any(), gomock.Any()).Times(1),
+				mockLogger.EXPECT().Printf(
+					"pkg  %v\tfailed %v coverage %v%%, minimum %v%%\n", "foo/bar", "line", float64(50), float64(60),
+				),
+				mockLogger.EXPECT().Printf(
+					"pkg  %v\tfailed %v coverage %v%%, minimum %v%%\n", "foo/bar", "function", float64(50), float64(100),
+				),
+			)
+
+			return testcase{
+				verifier: &Verifier{Out: mockLogger},
+				coverages: analyzer.NewPackageCoverages(map[string][]profile.FunctionCoverage{
+					"foo/bar": []profile.FunctionCoverage{
+						{CoveredCount: 9, StatementCount: 10, LineCount: 2, CoveredLineCount: 2},
+						{StatementCount: 1, LineCount: 2},
+					},
+				}),
+				pkg: config.ConfigPackage{
+					Name:                          "foo/bar",
+					MinCoveragePercentage:         80,
+					MinLineCoveragePercentage:     60,
+					MinFunctionCoveragePercentage: 100,
+				},
+			}
+		},
+		"statement counts outside of their limits": func(ctrl *gomock.Controller) testcase {
+			mockLogger := mock_reporter.NewMocklogger(ctrl)
+			gomock.InOrder(
+				mockLogger.EXPECT().Printf(gomock.Any(), gomock.Any()).Times(1),
+				mockLogger.EXPECT().Printf(
+					"pkg  %v\tfailed uncovered statements %v, maximum %v\n", "foo/bar", int64(5), int64(2),
+				),
+				mockLogger.EXPECT().Printf(
+					"pkg  %v\tfailed covered statements %v, minimum %v\n", "foo/bar", int64(5), int64(6),
+				),
+				mockLogger.EXPECT().Printf("pkg  %v\tcover %v more statements to pass\n", "foo/bar", int64(3)),
+			)
+
+			maxUncovered := int64(2)
+
+			return testcase{
+				verifier: &Verifier{Out: mockLogger},
+				coverages: analyzer.NewPackageCoverages(map[string][]profile.FunctionCoverage{
+					"foo/bar": []profile.FunctionCoverage{
+						{CoveredCount: 5, StatementCount: 10},
+					},
+				}),
+				pkg: config.ConfigPackage{
+					Name:                   "foo/bar",
+					MaxUncoveredStatements: &maxUncovered,
+					MinCoveredStatements:   6,
+				},
+			}
+		},
+		"statement counts within their limits": func(ctrl *gomock.Controller) testcase {
+			mockLogger := mock_reporter.NewMocklogger(ctrl)
+			mockLogger.EXPECT().Printf(gomock.Any(), gomock.Any()).Times(1)
+
+			maxUncovered := int64(0)
+
+			return testcase{
+				verifier: &Verifier{Out: mockLogger},
+				coverages: analyzer.NewPackageCoverages(map[string][]profile.FunctionCoverage{
+					"foo/bar": []profile.FunctionCoverage{
+						{CoveredCount: 10, StatementCount: 10},
+					},
+				}),
+				pkg: config.ConfigPackage{
+					Name:                   "foo/bar",
+					MaxUncoveredStatements: &maxUncovered,
+					MinCoveredStatements:   10,
+				},
+				result: true,
+			}
+		},
+		"only statement coverage without other minimums": func(ctrl *gomock.Controller) testcase {
+			mockLogger := mock_reporter.NewMocklogger(ctrl)
+			mockLogger.EXPECT().Printf("%v\n", "pkg  foo/bar\tcoverage 50% \tminimum 40% \tstatements\t5/10")
+
+			return testcase{
+				verifier: &Verifier{Out: mockLogger},
+				coverages: analyzer.NewPackageCoverages(map[string][]profile.FunctionCoverage{
+					"foo/bar": []profile.FunctionCoverage{
+						{CoveredCount: 5, StatementCount: 10, LineCount: 4, CoveredLineCount: 2},
+					},
+				}),
+				pkg: config.ConfigPackage{
+					Name:                  "foo/bar",
+					MinCoveragePercentage: 40,
+				},
+				result: true,
+			}
+		},
+		"metrics with a minimum after statement coverage": func(ctrl *gomock.Controller) testcase {
+			mockLogger := mock_reporter.NewMocklogger(ctrl)
+			mockLogger.EXPECT().Printf(
+				"%v\n",
+				"pkg  foo/bar\tcoverage 50% \tminimum 40% \tstatements\t5/10"+
+					"\tline coverage 50% \tminimum 30% \tlines\t2/4",
+			)
+
+			return testcase{
+				verifier: &Verifier{Out: mockLogger},
+				coverages: analyzer.NewPackageCoverages(map[string][]profile.FunctionCoverage{
+					"foo/bar": []profile.FunctionCoverage{
+						{CoveredCount: 5, StatementCount: 10, LineCount: 4, CoveredLineCount: 2},
+					},
+				}),
+				pkg: config.ConfigPackage{
+					Name:                      "foo/bar",
+					MinCoveragePercentage:     40,
+					MinLineCoveragePercentage: 30,
+				},
+				result: true,
+			}
+		},
+		"function over the maximum CRAP score": func(ctrl *gomock.Controller) testcase {
+			mockLogger := mock_reporter.NewMocklogger(ctrl)
+			mockLogger.EXPECT().Printf(gomock.Any(), gomock.Any()).Times(1)
+			mockLogger.EXPECT().Printf("func %v\tCRAP %v \tmaximum %v\n", "Risky", float64(30), float64(20))
+			mockLogger.EXPECT().Printf("\n")
+
+			return testcase{
+				verifier: &Verifier{Out: mockLogger},
+				coverages: analyzer.NewPackageCoverages(map[string][]profile.FunctionCoverage{
+					"foo/bar": []profile.FunctionCoverage{
+						{
+							Name:           "Safe",
+							CoveredCount:   10,
+							StatementCount: 10,
+							Function:       functions.Function{Complexity: 10},
+						},
+						{
+							Name:           "Risky",
+							StatementCount: 10,
+							Function:       functions.Function{Complexity: 5},
+						},
+					},
+				}),
+				pkg: config.ConfigPackage{
+					Name:         "foo/bar",
+					MaxCRAPScore: 20,
+				},
+			}
+		},
 	}
 
 	for i := range testCases {
@@ -198,6 +393,85 @@ min_coverage_percentage: 20
 packages:
 - name: baz
   min_coverage_percentage: 0
+`),
+			}
+		},
+		"package matched by a pattern with explain": func(ctrl *gomock.Controller) testcase {
+			mockLogger := mock_reporter.NewMocklogger(ctrl)
+
+			mockLogger.EXPECT().Printf("pkg  %v\tconfigured by %v\n", "foo/bar", `packages[1] "foo/..."`)
+			mockLogger.EXPECT().Printf(gomock.Any(), gomock.Any()).MinTimes(1)
+
+			return testcase{
+				verifier: &Verifier{Out: mockLogger, Explain: true},
+				input: map[string][]profile.FunctionCoverage{
+					"foo/bar": []profile.FunctionCoverage{
+						{CoveredCount: 1, StatementCount: 2},
+					},
+				},
+				expectErr: true,
+				configData: []byte(`
+min_coverage_percentage: 0
+packages:
+- name: baz
+  min_coverage_percentage: 0
+- name: foo/...
+  min_coverage_percentage: 60
+`),
+			}
+		},
+		"files with a rule also count towards their package": func(ctrl *gomock.Controller) testcase {
+			mockLogger := mock_reporter.NewMocklogger(ctrl)
+
+			mockLogger.EXPECT().Printf(
+				"file %v\tcoverage %v%% \tminimum %v%% \tstatements\t%v/%v\n",
+				"foo/bar/table.go", float64(0), float64(0), int64(0), int64(100),
+			)
+			mockLogger.EXPECT().Printf(gomock.Any(), gomock.Any()).AnyTimes()
+
+			return testcase{
+				verifier: &Verifier{Out: mockLogger},
+				input: map[string][]profile.FunctionCoverage{
+					"foo/bar": []profile.FunctionCoverage{
+						{CoveredCount: 9, StatementCount: 10, Function: functions.Function{SrcPath: "foo/bar/bar.go"}},
+						{StatementCount: 100, Function: functions.Function{SrcPath: "foo/bar/table.go"}},
+					},
+				},
+				expectErr: true,
+				configData: []byte(`
+min_coverage_percentage: 80
+files:
+- name: "**/table.go"
+  min_coverage_percentage: 0
+`),
+			}
+		},
+		"files with a rule excluded from their package": func(ctrl *gomock.Controller) testcase {
+			mockLogger := mock_reporter.NewMocklogger(ctrl)
+
+			gomock.InOrder(
+				mockLogger.EXPECT().Printf(gomock.Any(), gomock.Any()).Times(1),
+				mockLogger.EXPECT().Printf(
+					"file %v\tcoverage %v%% \tminimum %v%% \tstatements\t%v/%v\n",
+					"foo/bar/table.go", float64(0), float64(0), int64(0), int64(100),
+				),
+				mockLogger.EXPECT().Printf("\n"),
+			)
+
+			return testcase{
+				verifier: &Verifier{Out: mockLogger},
+				input: map[string][]profile.FunctionCoverage{
+					"foo/bar": []profile.FunctionCoverage{
+						{CoveredCount: 9, StatementCount: 10, Function: functions.Function{SrcPath: "foo/bar/bar.go"}},
+						{StatementCount: 100, Function: functions.Function{SrcPath: "foo/bar/table.go"}},
+					},
+				},
+				configData: []byte(`
+min_coverage_percentage: 80
+files:
+- name: "**/table.go"
+  min_coverage_percentage: 0
+  exclude_from_package: true
 `),
 			}
 		},
@@ -237,6 +511,200 @@ packages:
 	}
 }
 
+func Test_Verifier_ReportPackageCoverage(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	strict, err := config.ParseConfigFile([]byte("min_coverage_percentage: 90"))
+	g.Expect(err).To(BeNil())
+
+	mockLogger := mock_reporter.NewMocklogger(ctrl)
+	mockLogger.EXPECT().Printf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// foo/bar only fails the threshold of its own config, baz falls back to
+	// the flags of the verifier
+	v := Verifier{Out: mockLogger, MinCov: 40}
+	input := map[string][]profile.FunctionCoverage{
+		"foo/bar": {{CoveredCount: 1, StatementCount: 2}},
+		"baz":     {{CoveredCount: 1, StatementCount: 2}},
+	}
+
+	err = v.ReportPackageCoverage(input, map[string]*config.ConfigFile{"foo/bar": &strict})
+	g.Expect(err).ToNot(BeNil())
+
+	err = v.ReportPackageCoverage(input, map[string]*config.ConfigFile{})
+	g.Expect(err).To(BeNil())
+}
+
+func Test_Verifier_ReportPackageCoverage_profileOnly(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	cfg, err := config.ParseConfigFile([]byte(`
+min_branch_coverage_percentage: 80
+min_api_coverage_percentage: 70
+max_crap_score: 30
+packages:
+- name: foo/bar
+  min_coverage_percentage: 50
+  min_line_coverage_percentage: 60
+  min_function_coverage_percentage: 100
+  functions:
+  - name: foo/bar/a.go
+    min_coverage_percentage: 100
+`))
+	g.Expect(err).To(BeNil())
+
+	mockLogger := mock_reporter.NewMocklogger(ctrl)
+	gomock.InOrder(
+		mockLogger.EXPECT().Printf(
+			"pkg  %v\tskipped %v %v, not available with --profile-only\n",
+			"foo/bar", "min_line_coverage_percentage", float64(60),
+		),
+		mockLogger.EXPECT().Printf(
+			"pkg  %v\tskipped %v %v, not available with --profile-only\n",
+			"foo/bar", "min_function_coverage_percentage", float64(100),
+		),
+		mockLogger.EXPECT().Printf(
+			"pkg  %v\tskipped %v %v, not available with --profile-only\n", "foo/bar", "functions rules", 1,
+		),
+		// the metrics which need the source are left out
+		mockLogger.EXPECT().Printf("%v\n", "pkg  foo/bar\tcoverage 50% \tminimum 50% \tstatements\t1/2"),
+		mockLogger.EXPECT().Printf(
+			"pkg  %v\tskipped %v %v, not available with --profile-only\n",
+			"qux", "min_branch_coverage_percentage", float64(80),
+		),
+		mockLogger.EXPECT().Printf(
+			"pkg  %v\tskipped %v %v, not available with --profile-only\n",
+			"qux", "min_api_coverage_percentage", float64(70),
+		),
+		mockLogger.EXPECT().Printf(
+			"pkg  %v\tskipped %v %v, not available with --profile-only\n", "qux", "max_crap_score", float64(30),
+		),
+	)
+	mockLogger.EXPECT().Printf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// one of the two files of foo/bar is covered, which would fail the
+	// function rules if they were checked
+	v := Verifier{Out: mockLogger, ProfileOnly: true}
+	err = v.ReportPackageCoverage(map[string][]profile.FunctionCoverage{
+		"foo/bar": {
+			{Name: "foo/bar/a.go", StatementCount: 1},
+			{Name: "foo/bar/b.go", CoveredCount: 1, StatementCount: 1},
+		},
+		"qux": {{Name: "qux/c.go", CoveredCount: 1, StatementCount: 1}},
+	}, map[string]*config.ConfigFile{"foo/bar": &cfg, "qux": &cfg})
+	g.Expect(err).To(BeNil())
+}
+
+func Test_Verifier_ReportPackageCoverage_waivers(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	cfg, err := config.ParseConfigFile([]byte(`
+min_coverage_percentage: 90
+max_crap_score: 5
+waivers:
+- package: foo/legacy
+  reason: being replaced
+  owner: alice
+  expires: 2026-11-01
+- package: foo/bar
+  function: Risky
+  reason: needs a test double
+  owner: bob
+  expires: 2026-10-01
+- package: foo/bar
+  function: Charge*
+  reason: payment provider sandbox is down
+  owner: carol
+  expires: 2027-01-01
+`))
+	g.Expect(err).To(BeNil())
+
+	mockLogger := mock_reporter.NewMocklogger(ctrl)
+	gomock.InOrder(
+		mockLogger.EXPECT().Printf(
+			"func %v\tCRAP %v \tmaximum %v\twaived, %v\n", "ChargeCard", float64(6), float64(5), cfg.Waivers[2],
+		),
+		mockLogger.EXPECT().Printf("func %v\tCRAP %v \tmaximum %v\n", "Risky", float64(6), float64(5)),
+		mockLogger.EXPECT().Printf(
+			"pkg  %v\tfailed %v coverage %v%%, minimum %v%%\twaived, %v\n",
+			"foo/legacy", "statement", float64(0), float64(90), cfg.Waivers[0],
+		),
+		mockLogger.EXPECT().Printf(
+			"warning: %v owned by %v expires on %v, in %v days\n", `waivers[0] "foo/legacy"`, "alice", "2026-11-01", 14,
+		),
+		mockLogger.EXPECT().Printf(
+			"warning: %v owned by %v expired on %v and no longer applies\n",
+			`waivers[1] "foo/bar Risky"`, "bob", "2026-10-01",
+		),
+	)
+	mockLogger.EXPECT().Printf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	v := Verifier{Out: mockLogger, Now: time.Date(2026, 10, 18, 12, 0, 0, 0, time.Local), WaiverWarningDays: 30}
+	configs := map[string]*config.ConfigFile{"foo/legacy": &cfg, "foo/bar": &cfg}
+
+	// the failure of Risky still counts since its waiver expired
+	err = v.ReportPackageCoverage(map[string][]profile.FunctionCoverage{
+		"foo/legacy": {{StatementCount: 10}},
+		"foo/bar": {
+			{Name: "ChargeCard", StatementCount: 1, Function: functions.Function{Complexity: 2}},
+			{Name: "Risky", StatementCount: 1, Function: functions.Function{Complexity: 2}},
+			{Name: "Safe", CoveredCount: 20, StatementCount: 20},
+		},
+	}, configs)
+	g.Expect(err).ToNot(BeNil())
+
+	// once Risky is covered all remaining failures are waived
+	err = v.ReportPackageCoverage(map[string][]profile.FunctionCoverage{
+		"foo/legacy": {{StatementCount: 10}},
+		"foo/bar": {
+			{Name: "ChargeCard", StatementCount: 1, Function: functions.Function{Complexity: 2}},
+			{Name: "Risky", CoveredCount: 1, StatementCount: 1, Function: functions.Function{Complexity: 2}},
+			{Name: "Safe", CoveredCount: 20, StatementCount: 20},
+		},
+	}, configs)
+	g.Expect(err).To(BeNil())
+}
+
+func Test_Verifier_PrintConfigs(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	cfg, err := config.ParseConfigFile([]byte(`
+include_generated: true
+packages:
+- name: foo/...
+  min_coverage_percentage: 60
+`))
+	g.Expect(err).To(BeNil())
+
+	mockLogger := mock_reporter.NewMocklogger(ctrl)
+	gomock.InOrder(
+		mockLogger.EXPECT().Printf("pkg  %v\tconfigured by %v\n", "foo/bar", `packages[0] "foo/..."`),
+		mockLogger.EXPECT().Printf("  %v\n", "min_coverage_percentage: 60"),
+		mockLogger.EXPECT().Printf("  %v\n", "include_generated: true"),
+		mockLogger.EXPECT().Printf("\n"),
+		mockLogger.EXPECT().Printf("pkg  %v\tconfigured by %v\n", "qux", "command line flags"),
+		mockLogger.EXPECT().Printf("  %v\n", "min_coverage_percentage: 30"),
+		mockLogger.EXPECT().Printf("  %v\n", "include_generated: false"),
+		mockLogger.EXPECT().Printf("\n"),
+	)
+
+	v := Verifier{Out: mockLogger, MinCov: 30}
+	err = v.PrintConfigs([]string{"foo/bar", "qux"}, map[string]*config.ConfigFile{"foo/bar": &cfg})
+	g.Expect(err).To(BeNil())
+}
+
 func Test_Verifier_PrintReport(t *testing.T) {
 	type testcase struct {
 		verifier  *Verifier
@@ -291,3 +759,137 @@ func Test_Verifier_PrintReport(t *testing.T) {
 		})
 	}
 }
+
+func Test_Verifier_PrintUncoveredReport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := mock_reporter.NewMocklogger(ctrl)
+	gomock.InOrder(
+		mockLogger.EXPECT().Printf("uncovered %v:%v-%v\n", "foo/a.go", int64(3), int64(6)),
+		mockLogger.EXPECT().Printf("uncovered %v:%v-%v\n", "foo/a.go", int64(9), int64(9)),
+		mockLogger.EXPECT().Printf(
+			"branch    %v:%v\t%v\tran %v\tnot run %v\n", "foo/a.go", int64(4), "if", "line 5", "implicit else",
+		),
+		mockLogger.EXPECT().Printf("uncovered %v:%v-%v\n", "foo/b.go", int64(2), int64(2)),
+		mockLogger.EXPECT().Printf(
+			"branch    %v:%v\t%v\tran %v\tnot run %v\n", "foo/c.go", int64(3), "switch", "none", "line 4, implicit default",
+		),
+		mockLogger.EXPECT().Printf("\n"),
+	)
+
+	v := Verifier{Out: mockLogger}
+	v.PrintUncoveredReport([]profile.FunctionCoverage{
+		{Function: functions.Function{SrcPath: "foo/b.go", Statements: []statements.Statement{
+			{StartLine: 2, EndLine: 2},
+			{StartLine: 3, EndLine: 3, ExecutedCount: 1},
+		}}},
+		{Function: functions.Function{SrcPath: "foo/a.go", Statements: []statements.Statement{
+			{StartLine: 3, EndLine: 5},
+			{StartLine: 4, EndLine: 4},
+			{StartLine: 6, EndLine: 6},
+			{StartLine: 7, EndLine: 8, ExecutedCount: 2},
+			{StartLine: 9, EndLine: 9},
+		}, Branches: []statements.Branch{
+			{Kind: "if", StartLine: 4, Arms: []statements.Arm{
+				{Line: 5, ExecutedCount: 1},
+				{Line: 4, Implicit: true},
+			}},
+			{Kind: "if", StartLine: 7, Arms: []statements.Arm{
+				{Line: 8, ExecutedCount: 1},
+				{Line: 7, ExecutedCount: 1, Implicit: true},
+			}},
+		}}},
+		{Function: functions.Function{SrcPath: "foo/c.go", Branches: []statements.Branch{
+			{Kind: "switch", StartLine: 3, Arms: []statements.Arm{{Line: 4}, {Line: 3, Implicit: true}}},
+		}}},
+	})
+}
+
+func Test_statementsToCover(t *testing.T) {
+	maxUncovered := int64(1)
+
+	testCases := []struct {
+		desc     string
+		executed int64
+		total    int64
+		pkg      config.ConfigPackage
+		expected int64
+	}{
+		{desc: "no limits", executed: 0, total: 10},
+		{
+			desc: "percentage met", executed: 8, total: 10,
+			pkg: config.ConfigPackage{MinCoveragePercentage: 80},
+		},
+		{
+			desc: "percentage rounded down", executed: 0, total: 3, expected: 1,
+			pkg: config.ConfigPackage{MinCoveragePercentage: 33.33},
+		},
+		{
+			desc: "percentage of a large package", executed: 500, total: 1000, expected: 201,
+			pkg: config.ConfigPackage{MinCoveragePercentage: 70.01},
+		},
+		{
+			desc: "percentage met exactly", executed: 161, total: 250,
+			pkg: config.ConfigPackage{MinCoveragePercentage: 64.4},
+		},
+		{
+			desc: "percentage one short", executed: 160, total: 250, expected: 1,
+			pkg: config.ConfigPackage{MinCoveragePercentage: 64.4},
+		},
+		{
+			desc: "maximum uncovered", executed: 5, total: 10, expected: 4,
+			pkg: config.ConfigPackage{MinCoveragePercentage: 10, MaxUncoveredStatements: &maxUncovered},
+		},
+		{
+			desc: "minimum covered", executed: 2, total: 10, expected: 3,
+			pkg: config.ConfigPackage{MinCoveredStatements: 5},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			g.Expect(statementsToCover(tc.executed, tc.total, tc.pkg)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func Test_Verifier_ReportPackageCoverage_waivedStatements(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	cfg, err := config.ParseConfigFile([]byte(`
+min_coverage_percentage: 90
+max_uncovered_statements: 5
+waivers:
+- package: foo/legacy
+  reason: being replaced
+  owner: alice
+  expires: 2027-06-30
+`))
+	g.Expect(err).To(BeNil())
+
+	// no hint to cover more statements follows the waived failures
+	mockLogger := mock_reporter.NewMocklogger(ctrl)
+	gomock.InOrder(
+		mockLogger.EXPECT().Printf("%v\n", "pkg  foo/legacy\tcoverage 0% \tminimum 90% \tstatements\t0/10"),
+		mockLogger.EXPECT().Printf(
+			"pkg  %v\tfailed %v coverage %v%%, minimum %v%%\twaived, %v\n",
+			"foo/legacy", "statement", float64(0), float64(90), cfg.Waivers[0],
+		),
+		mockLogger.EXPECT().Printf(
+			"pkg  %v\tfailed uncovered statements %v, maximum %v\twaived, %v\n",
+			"foo/legacy", int64(10), int64(5), cfg.Waivers[0],
+		),
+	)
+
+	v := Verifier{Out: mockLogger, Now: time.Date(2026, 10, 18, 12, 0, 0, 0, time.Local)}
+	err = v.ReportPackageCoverage(map[string][]profile.FunctionCoverage{
+		"foo/legacy": {{StatementCount: 10}},
+	}, map[string]*config.ConfigFile{"foo/legacy": &cfg})
+	g.Expect(err).To(BeNil())
+}